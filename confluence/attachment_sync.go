@@ -0,0 +1,310 @@
+package confluence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const defaultAttachmentConcurrency = 4
+
+var attachmentHashPattern = regexp.MustCompile(`sha256:([0-9a-f]{64})`)
+
+// SourceAttachment — файл источника для SyncAttachments
+type SourceAttachment struct {
+	Title     string
+	Size      int64  // 0 — неизвестен
+	Hash      string // sha256 в hex, пусто — посчитать по содержимому
+	MediaType string
+	download  Attachment
+}
+
+// AttachmentSource — откуда брать вложения для SyncAttachments
+type AttachmentSource interface {
+	List(ctx context.Context) ([]SourceAttachment, error)
+	Open(ctx context.Context, attachment SourceAttachment) (io.ReadCloser, error)
+}
+
+// PageAttachments — вложения страницы Confluence как источник
+func PageAttachments(api ApiConfluence, pageID string) AttachmentSource {
+	return pageAttachments{api: api, pageID: pageID}
+}
+
+type pageAttachments struct {
+	api    ApiConfluence
+	pageID string
+}
+
+func (p pageAttachments) List(ctx context.Context) ([]SourceAttachment, error) {
+	attachments, err := p.api.GetAttachments(ctx, p.pageID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]SourceAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		res = append(res, SourceAttachment{
+			Title:     attachment.Title,
+			Size:      attachment.Size(),
+			Hash:      attachmentHash(attachment),
+			MediaType: attachment.MediaType(),
+			download:  attachment,
+		})
+	}
+	return res, nil
+}
+
+// Open — содержимое вложения потоком, скачивание идёт по мере чтения
+func (p pageAttachments) Open(ctx context.Context, attachment SourceAttachment) (io.ReadCloser, error) {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(p.api.DownloadAttachmentTo(ctx, attachment.download, pw))
+	}()
+	return pr, nil
+}
+
+// DirAttachments — файлы каталога (без вложенных каталогов) как источник
+func DirAttachments(dir string) AttachmentSource {
+	return dirAttachments(dir)
+}
+
+type dirAttachments string
+
+func (d dirAttachments) List(_ context.Context) ([]SourceAttachment, error) {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+	var res []SourceAttachment
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, SourceAttachment{Title: entry.Name(), Size: info.Size()})
+	}
+	return res, nil
+}
+
+func (d dirAttachments) Open(_ context.Context, attachment SourceAttachment) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), attachment.Title))
+}
+
+type SyncAttachmentsOptions struct {
+	DeleteExtra bool   // удалить вложения страницы, которых нет в источнике
+	Concurrency int    // одновременных загрузок, по умолчанию 4
+	Comment     string // комментарий к загруженным версиям, к нему дописывается хеш
+	MinorEdit   bool
+	NoHash      bool // не дописывать хеш в комментарий версии
+	DryRun      bool // только сравнить: результат тот же, но ничего не загружается и не удаляется
+}
+
+// SyncAttachmentsResult — названия вложений по результату синхронизации
+type SyncAttachmentsResult struct {
+	Uploaded []string
+	Skipped  []string
+	Deleted  []string
+}
+
+// SyncAttachments — привести вложения страницы dstPageID к источнику. Вложение загружается, только если
+// отличается размер или sha256. Хеш считается, только когда размеры совпадают; новые вложения и вложения
+// другого размера переливаются сразу. Известный хеш загруженной версии хранится в её комментарии, поэтому
+// при следующей синхронизации страницу не нужно скачивать; без хеша в комментарии он считается по содержимому
+func SyncAttachments(ctx context.Context, api ApiConfluence, src AttachmentSource, dstPageID string, opts SyncAttachmentsOptions) (SyncAttachmentsResult, error) {
+	var result SyncAttachmentsResult
+	sources, err := src.List(ctx)
+	if err != nil {
+		return result, fmt.Errorf("SyncAttachments — list source err: %w", err)
+	}
+	targets, err := api.GetAttachments(ctx, dstPageID)
+	if err != nil {
+		return result, fmt.Errorf("SyncAttachments — %w", err)
+	}
+	targetByTitle := make(map[string]Attachment, len(targets))
+	for _, target := range targets {
+		targetByTitle[target.Title] = target
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultAttachmentConcurrency
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		once     sync.Once
+		firstErr error
+		sem      = make(chan struct{}, opts.Concurrency)
+	)
+	for _, source := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			target, exists := targetByTitle[source.Title]
+			uploaded, err := syncAttachment(ctx, api, src, source, dstPageID, target, exists, opts)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("SyncAttachments — title %s err: %w", source.Title, err)
+					cancel()
+				})
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if uploaded {
+				result.Uploaded = append(result.Uploaded, source.Title)
+			} else {
+				result.Skipped = append(result.Skipped, source.Title)
+			}
+		}()
+	}
+	wg.Wait()
+	slices.Sort(result.Uploaded)
+	slices.Sort(result.Skipped)
+	if firstErr != nil {
+		return result, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return result, fmt.Errorf("SyncAttachments — %w", err)
+	}
+
+	if !opts.DeleteExtra {
+		return result, nil
+	}
+	for _, target := range targets {
+		if slices.ContainsFunc(sources, func(s SourceAttachment) bool { return s.Title == target.Title }) {
+			continue
+		}
+		if opts.DryRun {
+			result.Deleted = append(result.Deleted, target.Title)
+			continue
+		}
+		if err := api.DeleteAttachment(ctx, target.ID); err != nil {
+			return result, fmt.Errorf("SyncAttachments — %w", err)
+		}
+		result.Deleted = append(result.Deleted, target.Title)
+	}
+	return result, nil
+}
+
+// syncAttachment — загрузить вложение, если оно отличается от target. true — вложение загружено
+func syncAttachment(ctx context.Context, api ApiConfluence, src AttachmentSource, source SourceAttachment,
+	dstPageID string, target Attachment, exists bool, opts SyncAttachmentsOptions) (bool, error) {
+	sizeChanged := source.Size > 0 && target.Size() > 0 && source.Size != target.Size()
+	if !exists || sizeChanged {
+		if opts.DryRun {
+			return true, nil
+		}
+		// Сравнивать нечего — источник переливается без подсчёта хеша
+		r, err := src.Open(ctx, source)
+		if err != nil {
+			return false, err
+		}
+		defer r.Close()
+		return true, uploadSource(ctx, api, source, dstPageID, target.ID, r, opts)
+	}
+
+	targetHash := attachmentHash(target)
+	if targetHash == "" {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(api.DownloadAttachmentTo(ctx, target, pw))
+		}()
+		var err error
+		if targetHash, err = hashReader(pr, nil); err != nil {
+			return false, err
+		}
+	}
+	if source.Hash == "" && opts.DryRun {
+		hash, err := hashReader(src.Open(ctx, source))
+		return hash != targetHash, err
+	}
+	if source.Hash == "" {
+		// Источник читается один раз: пока считается хеш, копия пишется на диск для загрузки
+		spool, err := os.CreateTemp("", "attachment-*")
+		if err != nil {
+			return false, err
+		}
+		defer os.Remove(spool.Name())
+		defer spool.Close()
+		r, err := src.Open(ctx, source)
+		if err != nil {
+			return false, err
+		}
+		hash := sha256.New()
+		_, err = io.Copy(io.MultiWriter(spool, hash), r)
+		r.Close()
+		if err != nil {
+			return false, err
+		}
+		if source.Hash = hex.EncodeToString(hash.Sum(nil)); source.Hash == targetHash {
+			return false, nil
+		}
+		if _, err := spool.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		return true, uploadSource(ctx, api, source, dstPageID, target.ID, spool, opts)
+	}
+	if source.Hash == targetHash || opts.DryRun {
+		return source.Hash != targetHash, nil
+	}
+	r, err := src.Open(ctx, source)
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+	return true, uploadSource(ctx, api, source, dstPageID, target.ID, r, opts)
+}
+
+// uploadSource — загрузить вложение из r новой версией attachmentID или, если он пуст, новым вложением
+func uploadSource(ctx context.Context, api ApiConfluence, source SourceAttachment, dstPageID, attachmentID string,
+	r io.Reader, opts SyncAttachmentsOptions) error {
+	uploadOpts := AttachmentOptions{Comment: opts.Comment, MinorEdit: opts.MinorEdit, MediaType: source.MediaType}
+	if source.Hash != "" && !opts.NoHash {
+		uploadOpts.Comment = strings.TrimSpace(opts.Comment + " sha256:" + source.Hash)
+	}
+	if attachmentID != "" {
+		return api.UpdateAttachmentFrom(ctx, dstPageID, attachmentID, source.Title, r, uploadOpts)
+	}
+	return api.CreateAttachmentFrom(ctx, dstPageID, source.Title, r, uploadOpts)
+}
+
+// attachmentHash — sha256 из комментария версии вложения, если его туда записал SyncAttachments
+func attachmentHash(attachment Attachment) string {
+	for _, comment := range []string{attachment.Metadata.Comment, attachment.Extensions.Comment} {
+		if match := attachmentHashPattern.FindStringSubmatch(comment); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// hashReader — sha256 всего содержимого r в hex
+func hashReader(r io.ReadCloser, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
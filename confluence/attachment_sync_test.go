@@ -0,0 +1,162 @@
+package confluence
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAttachment struct {
+	Attachment
+	data []byte
+}
+
+// attachmentConfluence — заглушка ApiConfluence с вложениями страниц в памяти
+type attachmentConfluence struct {
+	ApiConfluence
+	mu        sync.Mutex
+	pages     map[string][]*fakeAttachment
+	downloads []string
+	uploads   []string
+	nextId    int
+}
+
+func (f *attachmentConfluence) add(pageID, title string, data []byte, comment string) {
+	f.nextId++
+	a := &fakeAttachment{data: data}
+	a.ID, a.Title = fmt.Sprint(f.nextId), title
+	a.Extensions.FileSize = int64(len(data))
+	a.Metadata.Comment = comment
+	f.pages[pageID] = append(f.pages[pageID], a)
+}
+
+func (f *attachmentConfluence) GetAttachments(_ context.Context, pageID string) ([]Attachment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res []Attachment
+	for _, a := range f.pages[pageID] {
+		res = append(res, a.Attachment)
+	}
+	return res, nil
+}
+
+func (f *attachmentConfluence) DownloadAttachmentTo(_ context.Context, attachment Attachment, w io.Writer) error {
+	f.mu.Lock()
+	var data []byte
+	for _, attachments := range f.pages {
+		for _, a := range attachments {
+			if a.ID == attachment.ID {
+				data = a.data
+			}
+		}
+	}
+	f.downloads = append(f.downloads, attachment.Title)
+	f.mu.Unlock()
+	_, err := w.Write(data)
+	return err
+}
+
+func (f *attachmentConfluence) upload(pageID, attachmentID, title string, r io.Reader, opts AttachmentOptions) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.uploads = append(f.uploads, title)
+	f.pages[pageID] = slices.DeleteFunc(f.pages[pageID], func(a *fakeAttachment) bool { return a.ID == attachmentID })
+	f.add(pageID, title, data, opts.Comment)
+	return nil
+}
+
+func (f *attachmentConfluence) CreateAttachmentFrom(_ context.Context, pageID, title string, r io.Reader, opts AttachmentOptions) error {
+	return f.upload(pageID, "", title, r, opts)
+}
+
+func (f *attachmentConfluence) UpdateAttachmentFrom(_ context.Context, pageID, attachmentID, title string, r io.Reader, opts AttachmentOptions) error {
+	return f.upload(pageID, attachmentID, title, r, opts)
+}
+
+func (f *attachmentConfluence) DeleteAttachment(_ context.Context, attachmentID string) error {
+	for pageID := range f.pages {
+		f.pages[pageID] = slices.DeleteFunc(f.pages[pageID], func(a *fakeAttachment) bool { return a.ID == attachmentID })
+	}
+	return nil
+}
+
+func TestSyncAttachments(t *testing.T) {
+	ctx := context.Background()
+	newApi := func() *attachmentConfluence {
+		api := &attachmentConfluence{pages: map[string][]*fakeAttachment{}}
+		api.add("src", "same.txt", []byte("одинаковое"), "")
+		api.add("src", "changed.txt", []byte("свежее"), "")
+		api.add("src", "resized.txt", []byte("длиннее, чем было"), "")
+		api.add("src", "new.txt", []byte("новый файл"), "")
+		api.add("dst", "same.txt", []byte("одинаковое"), "")
+		api.add("dst", "changed.txt", []byte("старое"), "")
+		api.add("dst", "resized.txt", []byte("коротко"), "")
+		api.add("dst", "extra.txt", []byte("лишний"), "")
+		return api
+	}
+
+	t.Run("01. Загружаются только изменившиеся", func(t *testing.T) {
+		api := newApi()
+		res, err := SyncAttachments(ctx, api, PageAttachments(api, "src"), "dst", SyncAttachmentsOptions{Concurrency: 2})
+		require.NoError(t, err)
+		require.Equal(t, []string{"changed.txt", "new.txt", "resized.txt"}, res.Uploaded)
+		require.Equal(t, []string{"same.txt"}, res.Skipped)
+		require.Empty(t, res.Deleted)
+		require.Len(t, api.pages["dst"], 5)
+		// Хеш считается только при равных размерах, источник при этом скачивается один раз
+		slices.Sort(api.downloads)
+		require.Equal(t, []string{"changed.txt", "changed.txt", "new.txt", "resized.txt", "same.txt", "same.txt"}, api.downloads)
+
+		// Хеш changed.txt сохранён в комментарии: повторная синхронизация не скачивает его со страницы
+		api.downloads = nil
+		res, err = SyncAttachments(ctx, api, PageAttachments(api, "src"), "dst", SyncAttachmentsOptions{})
+		require.NoError(t, err)
+		require.Empty(t, res.Uploaded)
+		slices.Sort(api.downloads)
+		require.Equal(t, []string{"changed.txt", "new.txt", "new.txt", "resized.txt", "resized.txt", "same.txt", "same.txt"}, api.downloads)
+	})
+
+	t.Run("02. Удаление лишних", func(t *testing.T) {
+		api := newApi()
+		res, err := SyncAttachments(ctx, api, PageAttachments(api, "src"), "dst", SyncAttachmentsOptions{DeleteExtra: true})
+		require.NoError(t, err)
+		require.Equal(t, []string{"extra.txt"}, res.Deleted)
+		require.Len(t, api.pages["dst"], 4)
+	})
+
+	t.Run("03. Локальный каталог как источник", func(t *testing.T) {
+		api := newApi()
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "same.txt"), []byte("одинаковое"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "changed.txt"), []byte("свежее"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "report.pdf"), []byte("%PDF-1.4"), 0o644))
+		require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0o755))
+		res, err := SyncAttachments(ctx, api, DirAttachments(dir), "dst", SyncAttachmentsOptions{Comment: "сборка 1.3"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"changed.txt", "report.pdf"}, res.Uploaded)
+		require.Equal(t, []string{"same.txt"}, res.Skipped)
+		for _, uploaded := range api.pages["dst"] {
+			switch uploaded.Title {
+			case "report.pdf":
+				require.True(t, bytes.Equal([]byte("%PDF-1.4"), uploaded.data))
+				require.Equal(t, "сборка 1.3", uploaded.Metadata.Comment)
+			case "changed.txt":
+				// Хеш посчитан для сравнения и дописан в комментарий
+				require.Equal(t, []byte("свежее"), uploaded.data)
+				require.Regexp(t, `^сборка 1\.3 sha256:[0-9a-f]{64}$`, uploaded.Metadata.Comment)
+			}
+		}
+	})
+}
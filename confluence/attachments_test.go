@@ -0,0 +1,115 @@
+package confluence
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// uploadedPart — поля multipart-запроса загрузки вложения
+type uploadedPart struct {
+	path, filename, mediaType, comment, minorEdit string
+	data                                          []byte
+}
+
+func TestAttachmentsStreaming(t *testing.T) {
+	var zipData bytes.Buffer
+	zw := zip.NewWriter(&zipData)
+	_, err := zw.Create("readme.txt")
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	var uploads []uploadedPart
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/content/{id}/child/attachment", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, ExpandVersion, r.URL.Query().Get("expand"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.PathValue("id") != "10" {
+			_, _ = w.Write([]byte(`{"results": [], "start": 0, "limit": 100, "size": 0}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results": [{
+			"id": "att1", "title": "dist.zip", "version": {"number": 3},
+			"metadata": {"mediaType": "application/zip", "comment": "сборка 1.2"},
+			"extensions": {"mediaType": "application/zip", "fileSize": 22},
+			"_links": {"download": "/download/attachments/10/dist.zip"}
+		}], "start": 0, "limit": 100, "size": 1}`))
+	})
+	mux.HandleFunc("GET /download/attachments/10/dist.zip", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(zipData.Bytes())
+	})
+	upload := func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "no-check", r.Header.Get("X-Atlassian-Token"))
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		uploads = append(uploads, uploadedPart{
+			path:      r.URL.Path,
+			filename:  header.Filename,
+			mediaType: header.Header.Get("Content-Type"),
+			comment:   r.FormValue("comment"),
+			minorEdit: r.FormValue("minorEdit"),
+			data:      data,
+		})
+	}
+	mux.HandleFunc("POST /rest/api/content/{id}/child/attachment", upload)
+	mux.HandleFunc("POST /rest/api/content/{id}/child/attachment/{attachmentId}/data", upload)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "user", "password")
+	ctx := context.Background()
+
+	t.Run("01. Размер, тип и версия вложения", func(t *testing.T) {
+		attachments, err := c.GetAttachments(ctx, "10")
+		require.NoError(t, err)
+		require.Len(t, attachments, 1)
+		require.EqualValues(t, 22, attachments[0].Size())
+		require.Equal(t, "application/zip", attachments[0].MediaType())
+		require.Equal(t, 3, attachments[0].Version.Number)
+		require.Equal(t, "сборка 1.2", attachments[0].Metadata.Comment)
+	})
+
+	t.Run("02. Скачивание в io.Writer", func(t *testing.T) {
+		attachments, err := c.GetAttachments(ctx, "10")
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, c.DownloadAttachmentTo(ctx, attachments[0], &buf))
+		require.Equal(t, zipData.Bytes(), buf.Bytes())
+	})
+
+	t.Run("03. Загрузка из io.Reader с определением типа", func(t *testing.T) {
+		uploads = nil
+		require.NoError(t, c.CreateAttachmentFrom(ctx, "20", "сборка", bytes.NewReader(zipData.Bytes()),
+			AttachmentOptions{Comment: "релиз 1.3", MinorEdit: true}))
+		require.NoError(t, c.UpdateAttachmentFrom(ctx, "20", "att9", "NOTES", strings.NewReader("заметки"), AttachmentOptions{}))
+		require.NoError(t, c.CreateAttachment(ctx, "20", "schema.json", []byte(`{}`)))
+		require.Equal(t, []uploadedPart{
+			{path: "/rest/api/content/20/child/attachment", filename: "сборка", mediaType: "application/zip",
+				comment: "релиз 1.3", minorEdit: "true", data: zipData.Bytes()},
+			{path: "/rest/api/content/20/child/attachment/att9/data", filename: "NOTES", mediaType: "text/plain; charset=utf-8",
+				minorEdit: "false", data: []byte("заметки")},
+			{path: "/rest/api/content/20/child/attachment", filename: "schema.json", mediaType: "application/json",
+				minorEdit: "false", data: []byte(`{}`)},
+		}, uploads)
+	})
+
+	t.Run("04. Копирование потоком", func(t *testing.T) {
+		uploads = nil
+		require.NoError(t, c.CopyAttachments(ctx, "10", "30"))
+		require.Len(t, uploads, 1)
+		require.Equal(t, "dist.zip", uploads[0].filename)
+		require.Equal(t, "application/zip", uploads[0].mediaType)
+		require.Equal(t, zipData.Bytes(), uploads[0].data)
+		require.Equal(t, "true", uploads[0].minorEdit)
+		require.Empty(t, uploads[0].comment)
+	})
+}
@@ -0,0 +1,338 @@
+package confluence
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Node — фрагмент документа в формате хранения Confluence (storage XHTML)
+type Node interface {
+	render(b *strings.Builder)
+}
+
+type textNode string
+
+func (n textNode) render(b *strings.Builder) {
+	b.WriteString(Escape(string(n)))
+}
+
+type rawNode string
+
+func (n rawNode) render(b *strings.Builder) {
+	b.WriteString(string(n))
+}
+
+// Text — текст, спецсимволы экранируются
+func Text(text string) Node {
+	return textNode(text)
+}
+
+// Escape — экранировать текст перед подстановкой в шаблоны из templates.go
+func Escape(text string) string {
+	return html.EscapeString(text)
+}
+
+// Raw — готовый фрагмент XHTML, вставляется как есть. Например, шаблоны из templates.go
+func Raw(xhtml string) Node {
+	return rawNode(xhtml)
+}
+
+// element — тег с атрибутами и вложенными узлами
+type element struct {
+	tag      string
+	attrs    [][2]string
+	children []Node
+}
+
+func newElement(tag string, children ...Node) *element {
+	return &element{tag: tag, children: children}
+}
+
+func (e *element) attr(name, value string) *element {
+	for i := range e.attrs {
+		if e.attrs[i][0] == name {
+			e.attrs[i][1] = value
+			return e
+		}
+	}
+	e.attrs = append(e.attrs, [2]string{name, value})
+	return e
+}
+
+func (e *element) render(b *strings.Builder) {
+	b.WriteString("<" + e.tag)
+	for _, attr := range e.attrs {
+		fmt.Fprintf(b, ` %s="%s"`, attr[0], html.EscapeString(attr[1]))
+	}
+	if len(e.children) == 0 && isVoidTag(e.tag) {
+		b.WriteString(" />")
+		return
+	}
+	b.WriteString(">")
+	for _, child := range e.children {
+		child.render(b)
+	}
+	b.WriteString("</" + e.tag + ">")
+}
+
+func isVoidTag(tag string) bool {
+	switch tag {
+	case "br", "hr", "col", "img", "ri:page", "ri:attachment", "ri:user", "ac:emoticon":
+		return true
+	}
+	return false
+}
+
+// Strong — жирный текст
+func Strong(text string) Node { return newElement("strong", Text(text)) }
+
+// InlineCode — моноширинный текст
+func InlineCode(text string) Node { return newElement("code", Text(text)) }
+
+// ExternalLink — внешняя ссылка
+func ExternalLink(href, text string) Node { return newElement("a", Text(text)).attr("href", href) }
+
+// JiraIssue — макрос ссылки на задачу Jira, как в шаблоне JiraLink
+func JiraIssue(key string) Node {
+	return Raw(fmt.Sprintf(JiraLink, html.EscapeString(key)))
+}
+
+// PageLink — ссылка на страницу по пространству и названию. Пустой text — выводится название страницы
+func PageLink(spaceKey, title, text string) Node {
+	page := newElement("ri:page").attr("ri:content-title", title)
+	if spaceKey != "" {
+		page.attr("ri:space-key", spaceKey)
+	}
+	link := newElement("ac:link", page)
+	if text != "" {
+		link.children = append(link.children, newElement("ac:plain-text-link-body", cdata(text)))
+	}
+	return link
+}
+
+// cdata — текст в секции CDATA; "]]>" внутри текста разбивается на две секции
+func cdata(text string) Node {
+	return Raw("<![CDATA[" + strings.ReplaceAll(text, "]]>", "]]]]><![CDATA[>") + "]]>")
+}
+
+// Doc — построитель документа в формате хранения. Текст экранируется, методы можно вызывать цепочкой:
+//
+//	doc := confluence.NewDoc().H2("Задачи релиза")
+//	table := doc.Table().Header("Задача", "Тема")
+//	table.Row().Node(confluence.JiraIssue("CDI-1")).Cell("Исправлена выгрузка <xml>")
+//	doc.Macro("expand").Param("title", "Подробнее").Body().P("...")
+//	content := doc.String()
+type Doc struct {
+	children []Node
+}
+
+func NewDoc() *Doc {
+	return &Doc{}
+}
+
+func (d *Doc) render(b *strings.Builder) {
+	for _, child := range d.children {
+		child.render(b)
+	}
+}
+
+// String — документ в формате хранения
+func (d *Doc) String() string {
+	var b strings.Builder
+	d.render(&b)
+	return b.String()
+}
+
+// Add — добавить произвольные узлы
+func (d *Doc) Add(nodes ...Node) *Doc {
+	d.children = append(d.children, nodes...)
+	return d
+}
+
+// Raw — добавить готовый фрагмент XHTML без экранирования
+func (d *Doc) Raw(xhtml string) *Doc { return d.Add(Raw(xhtml)) }
+
+func (d *Doc) H2(text string) *Doc { return d.Add(newElement("h2", Text(text))) }
+func (d *Doc) H3(text string) *Doc { return d.Add(newElement("h3", Text(text))) }
+func (d *Doc) H4(text string) *Doc { return d.Add(newElement("h4", Text(text))) }
+
+// P — абзац с текстом
+func (d *Doc) P(text string) *Doc { return d.Add(newElement("p", Text(text))) }
+
+// Paragraph — абзац из произвольных узлов, например текста со ссылками
+func (d *Doc) Paragraph(nodes ...Node) *Doc { return d.Add(newElement("p", nodes...)) }
+
+// Hr — горизонтальная линия, как CheckLine
+func (d *Doc) Hr() *Doc { return d.Raw(CheckLine) }
+
+// List — маркированный список из текстовых пунктов
+func (d *Doc) List(items ...string) *Doc {
+	ul := newElement("ul")
+	for _, item := range items {
+		ul.children = append(ul.children, newElement("li", Text(item)))
+	}
+	return d.Add(ul)
+}
+
+func (d *Doc) JiraIssue(key string) *Doc { return d.Add(JiraIssue(key)) }
+
+func (d *Doc) PageLink(spaceKey, title, text string) *Doc {
+	return d.Add(PageLink(spaceKey, title, text))
+}
+
+// Table — добавить таблицу в документ
+func (d *Doc) Table() *Table {
+	t := &Table{}
+	d.Add(t)
+	return t
+}
+
+// Macro — добавить макрос в документ
+func (d *Doc) Macro(name string) *Macro {
+	m := NewMacro(name)
+	d.Add(m)
+	return m
+}
+
+// Table — таблица, строки добавляются через Row
+type Table struct {
+	rows []*Row
+}
+
+func (t *Table) render(b *strings.Builder) {
+	b.WriteString("<table><tbody>")
+	for _, row := range t.rows {
+		row.render(b)
+	}
+	b.WriteString("</tbody></table>")
+}
+
+// Header — строка заголовков
+func (t *Table) Header(titles ...string) *Table {
+	row := t.Row()
+	for _, title := range titles {
+		row.Th(title)
+	}
+	return t
+}
+
+// Row — добавить строку
+func (t *Table) Row() *Row {
+	r := &Row{table: t, element: newElement("tr")}
+	t.rows = append(t.rows, r)
+	return r
+}
+
+// Row — строка таблицы
+type Row struct {
+	*element
+	table *Table
+}
+
+// Style — стиль строки, например Top или Middle из templates.go
+func (r *Row) Style(style string) *Row {
+	r.attr("style", style)
+	return r
+}
+
+// Cell — ячейка с текстом
+func (r *Row) Cell(text string) *Cell { return r.Node(Text(text)) }
+
+// Th — ячейка заголовка
+func (r *Row) Th(text string) *Cell { return r.addCell("th", Text(text)) }
+
+// Node — ячейка с произвольным содержимым
+func (r *Row) Node(nodes ...Node) *Cell { return r.addCell("td", nodes...) }
+
+// Row — следующая строка той же таблицы
+func (r *Row) Row() *Row { return r.table.Row() }
+
+func (r *Row) addCell(tag string, nodes ...Node) *Cell {
+	c := &Cell{row: r, element: newElement(tag, nodes...)}
+	r.children = append(r.children, c)
+	return c
+}
+
+// Cell — ячейка таблицы. Методы Cell, Th, Node и Row продолжают строку и таблицу
+type Cell struct {
+	*element
+	row *Row
+}
+
+func (c *Cell) Style(style string) *Cell {
+	c.attr("style", style)
+	return c
+}
+
+func (c *Cell) ColSpan(n int) *Cell {
+	c.attr("colspan", fmt.Sprint(n))
+	return c
+}
+
+func (c *Cell) RowSpan(n int) *Cell {
+	c.attr("rowspan", fmt.Sprint(n))
+	return c
+}
+
+// Add — дописать узлы в ячейку
+func (c *Cell) Add(nodes ...Node) *Cell {
+	c.children = append(c.children, nodes...)
+	return c
+}
+
+func (c *Cell) Cell(text string) *Cell   { return c.row.Cell(text) }
+func (c *Cell) Th(text string) *Cell     { return c.row.Th(text) }
+func (c *Cell) Node(nodes ...Node) *Cell { return c.row.Node(nodes...) }
+func (c *Cell) Row() *Row                { return c.row.Row() }
+
+// Macro — макрос ac:structured-macro с параметрами и телом
+type Macro struct {
+	name      string
+	params    [][2]string
+	body      *Doc
+	plainBody *string
+}
+
+// NewMacro — макрос, который можно вставить в ячейку или другой макрос
+func NewMacro(name string) *Macro {
+	return &Macro{name: name}
+}
+
+// Param — параметр макроса, значение экранируется
+func (m *Macro) Param(name, value string) *Macro {
+	m.params = append(m.params, [2]string{name, value})
+	return m
+}
+
+// Body — тело макроса в формате rich text (expand, info, hfl...)
+func (m *Macro) Body() *Doc {
+	if m.body == nil {
+		m.body = NewDoc()
+	}
+	return m.body
+}
+
+// PlainBody — текстовое тело макроса (code, noformat), записывается как CDATA без экранирования
+func (m *Macro) PlainBody(text string) *Macro {
+	m.plainBody = &text
+	return m
+}
+
+func (m *Macro) render(b *strings.Builder) {
+	fmt.Fprintf(b, `<ac:structured-macro ac:name="%s" ac:schema-version="1">`, html.EscapeString(m.name))
+	for _, param := range m.params {
+		fmt.Fprintf(b, `<ac:parameter ac:name="%s">%s</ac:parameter>`, html.EscapeString(param[0]), html.EscapeString(param[1]))
+	}
+	if m.plainBody != nil {
+		b.WriteString("<ac:plain-text-body>")
+		cdata(*m.plainBody).render(b)
+		b.WriteString("</ac:plain-text-body>")
+	}
+	if m.body != nil {
+		b.WriteString("<ac:rich-text-body>")
+		m.body.render(b)
+		b.WriteString("</ac:rich-text-body>")
+	}
+	b.WriteString("</ac:structured-macro>")
+}
@@ -0,0 +1,66 @@
+package confluence
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoc(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() *Doc
+		want  string
+	}{
+		{
+			name: "01. Заголовок и абзац экранируются",
+			build: func() *Doc {
+				return NewDoc().H2("Задачи <релиза>").P(`Тема "A & B"`)
+			},
+			want: `<h2>Задачи &lt;релиза&gt;</h2><p>Тема &#34;A &amp; B&#34;</p>`,
+		},
+		{
+			name: "02. Таблица со стилями и объединением",
+			build: func() *Doc {
+				doc := NewDoc()
+				doc.Table().Header("Поле", "Описание").
+					Row().Style(Top).Cell("id").ColSpan(2).
+					Row().Cell("a<b").Node(InlineCode("x"), Raw(Br))
+				return doc
+			},
+			want: `<table><tbody><tr><th>Поле</th><th>Описание</th></tr>` +
+				`<tr style="border-top: 3.0px solid grey;"><td colspan="2">id</td></tr>` +
+				`<tr><td>a&lt;b</td><td><code>x</code><br></br></td></tr></tbody></table>`,
+		},
+		{
+			name: "03. Макрос с параметром и вложенным телом",
+			build: func() *Doc {
+				doc := NewDoc()
+				doc.Macro("expand").Param("title", "Подробнее <...>").Body().P("текст")
+				doc.Macro("code").PlainBody("if a ]]> b {}")
+				return doc
+			},
+			want: `<ac:structured-macro ac:name="expand" ac:schema-version="1">` +
+				`<ac:parameter ac:name="title">Подробнее &lt;...&gt;</ac:parameter>` +
+				`<ac:rich-text-body><p>текст</p></ac:rich-text-body></ac:structured-macro>` +
+				`<ac:structured-macro ac:name="code" ac:schema-version="1">` +
+				`<ac:plain-text-body><![CDATA[if a ]]]]><![CDATA[> b {}]]></ac:plain-text-body></ac:structured-macro>`,
+		},
+		{
+			name: "04. Ссылки на задачу и страницу",
+			build: func() *Doc {
+				return NewDoc().JiraIssue("CDI-1").PageLink("DOC", `Релиз "1.0"`, "релиз").PageLink("", "Главная", "")
+			},
+			want: fmt.Sprintf(JiraLink, "CDI-1") +
+				`<ac:link><ri:page ri:content-title="Релиз &#34;1.0&#34;" ri:space-key="DOC" />` +
+				`<ac:plain-text-link-body><![CDATA[релиз]]></ac:plain-text-link-body></ac:link>` +
+				`<ac:link><ri:page ri:content-title="Главная" /></ac:link>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.build().String())
+		})
+	}
+}
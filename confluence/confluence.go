@@ -1,15 +1,20 @@
 package confluence
 
 import (
+	"bufio"
 	"bytes"
+	"cmp"
 	"context"
-	"crypto/md5"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -20,23 +25,21 @@ func NewConfluence(baseUrl, user, password string) ApiConfluence {
 	return &confluence{user: user, password: password, baseUrl: baseUrl}
 }
 
+// GetPagesByName — страницы с точным заголовком. Ищутся через /content, а не CQL: индекс поиска отстаёт
+// от только что созданных страниц. Пустой spaceKey — поиск во всех пространствах
 func (c *confluence) GetPagesByName(ctx context.Context, name, spaceKey string) ([]PageInfo, error) {
-	var resp searchPagesResponse
-	err := requests.
+	builder := requests.
 		URL(c.baseUrl).
-		Method(http.MethodGet).
 		Param("title", name).
-		Param("spaceKey", spaceKey).
-		Param("type", "page").
-		ContentType("application/json").
-		BasicAuth(c.user, c.password).
-		ToJSON(&resp).
-		AddValidator(validateStatus).
-		Fetch(ctx)
+		Param("type", "page")
+	if spaceKey != "" {
+		builder.Param("spaceKey", spaceKey)
+	}
+	pages, err := collectAll(paginate[PageInfo](ctx, c, builder, defaultSearchLimit))
 	if err != nil {
-		return resp.Results, fmt.Errorf("GetPagesByName — get confluence page by name %s in space %s err: %w", name, spaceKey, err)
+		return pages, fmt.Errorf("GetPagesByName — get confluence page by name %s in space %s err: %w", name, spaceKey, err)
 	}
-	return resp.Results, nil
+	return pages, nil
 }
 
 func (c *confluence) GetChildPageByName(ctx context.Context, parentPageId, name string) (PageInfo, bool, error) {
@@ -61,38 +64,57 @@ func (c *confluence) GetChildPageByName(ctx context.Context, parentPageId, name
 	return PageInfo{}, false, nil
 }
 
+// GetPagesByIncludedName — страницы, в заголовке которых есть name (CQL title ~). Пустой spaceKey — поиск во всех пространствах
 func (c *confluence) GetPagesByIncludedName(ctx context.Context, name, spaceKey string) ([]PageInfo, error) {
-	cqlQuery := fmt.Sprintf("space=\"%s\" AND type=\"page\" AND title~\"%s\"", spaceKey, name)
-	var resp searchPagesResponse
+	cql := NewCQL()
+	if spaceKey != "" {
+		cql.Space(spaceKey)
+	}
+	pages, err := c.searchAllCQL(ctx, cql.Type("page").TitleContains(name).String())
+	if err != nil {
+		return pages, fmt.Errorf("GetPagesByIncludedName — get confluence page by include name %s in space %s err: %w", name, spaceKey, err)
+	}
+	return pages, nil
+}
+
+func (c *confluence) GetContentById(ctx context.Context, id string) (string, error) {
+	var resp PageInfo
 	err := requests.
-		URL(fmt.Sprintf("%s/search", c.baseUrl)).
+		URL(fmt.Sprintf("%s/%s", c.baseUrl, id)).
 		Method(http.MethodGet).
-		Param("cql", cqlQuery).
-		ContentType("application/json").
+		Param("expand", "body.storage").
 		BasicAuth(c.user, c.password).
 		ToJSON(&resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
-		return resp.Results, fmt.Errorf("GetPagesByIncludedName — get confluence page by include name %s in space %s err: %w", name, spaceKey, err)
+		return "", fmt.Errorf("GetContentById — get confluence pageId %s err: %w", id, err)
 	}
-	return resp.Results, nil
+	return resp.Body.Storage.Value, err
 }
 
-func (c *confluence) GetContentById(ctx context.Context, id string) (string, error) {
+// GetPage — страница с телом и метаданными, набор которых задаётся opts.Expand
+func (c *confluence) GetPage(ctx context.Context, id string, opts GetPageOptions) (PageInfo, error) {
+	if id == "" {
+		return PageInfo{}, fmt.Errorf("id cannot be empty")
+	}
+	expand := opts.Expand
+	if len(expand) == 0 {
+		expand = DefaultPageExpand
+	}
 	var resp PageInfo
 	err := requests.
 		URL(fmt.Sprintf("%s/%s", c.baseUrl, id)).
 		Method(http.MethodGet).
-		Param("expand", "body.storage").
+		Param("expand", strings.Join(expand, ",")).
 		BasicAuth(c.user, c.password).
 		ToJSON(&resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
-		return "", fmt.Errorf("GetContentById — get confluence pageId %s err: %w", id, err)
+		return resp, fmt.Errorf("GetPage — get confluence pageId %s err: %w", id, err)
 	}
-	return resp.Body.Storage.Value, err
+	return resp, nil
 }
 
 func (c *confluence) GetVersionById(ctx context.Context, id string) (VersionResponse, error) {
@@ -170,51 +192,48 @@ func (c *confluence) CreatePage(ctx context.Context, name, spaceKey, content, pa
 }
 
 func (c *confluence) CreatePageWithHash(ctx context.Context, name, spaceKey, content, parentPageId string) (string, error) {
-	hash := md5.Sum([]byte(content))
-	hashcode := hex.EncodeToString(hash[:])
-	content = fmt.Sprintf(HideHash, hashcode) + "\n" + content
+	content = fmt.Sprintf(HideHash, hashContent(content)) + "\n" + content
 	return c.CreatePage(ctx, name, spaceKey, content, parentPageId)
 }
 
-func (c *confluence) UpdatePageById(ctx context.Context, id string, content string, reCreate bool) error {
-	versionInfo, err := c.GetVersionById(ctx, id)
-	if err != nil {
-		return err
-	}
-
-	req := PageInfo{
-		Type:    "page",
-		Title:   versionInfo.Title,
-		Id:      id,
-		Version: &PageVersion{Number: versionInfo.Version.Number + 1},
-		Body:    &PageBody{PageStorage{Value: content, Representation: "storage"}},
-	}
-	oldContent, err := c.GetContentById(ctx, id)
-	if err != nil {
-		return err
-	}
-	// Проверяем есть ли "линия" на странице, чтобы сохранить контент до или после неё
-	if !reCreate && strings.Contains(oldContent, CheckLine) {
-		partsContent := strings.Split(oldContent, CheckLine)
-		switch len(partsContent) {
-		case 2:
-			contentToSave := partsContent[1]
-			req.Body.Storage.Value = content + CheckLine + contentToSave
-		case 3:
-			contentToSaveStart, contentToSaveEnd := partsContent[0], partsContent[2]
-			req.Body.Storage.Value = contentToSaveStart + CheckLine + content + CheckLine + contentToSaveEnd
+func (c *confluence) UpdatePageById(ctx context.Context, id string, content string, reCreate bool, opts ...UpdateOptions) error {
+	err := c.updatePageWithRetry(ctx, id, func(page PageInfo) (PageInfo, bool, error) {
+		var oldContent string
+		if page.Body != nil {
+			oldContent = page.Body.Storage.Value
 		}
-	}
-	err = c.updatePage(ctx, id, req)
+		req := PageInfo{
+			Type:    "page",
+			Body:    &PageBody{Storage: PageStorage{Value: mergeCheckLine(oldContent, content, reCreate), Representation: "storage"}},
+			Version: mergeUpdateOptions(opts).version(),
+		}
+		return req, true, nil
+	}, ExpandBodyStorage)
 	if err != nil {
 		return fmt.Errorf("UpdatePageById — update confluence pageId %s, content %s err: %w", id, content, err)
 	}
 	return nil
 }
 
-func (c *confluence) UpdatePageByIdWithCheck(ctx context.Context, id string, content string, reCreate bool) error {
-	hash := md5.Sum([]byte(content))
-	hashcode := hex.EncodeToString(hash[:])
+// mergeCheckLine — новое содержимое страницы с сохранением ручного текста до или после "линии" CheckLine
+func mergeCheckLine(oldContent, content string, reCreate bool) string {
+	if reCreate || !strings.Contains(oldContent, CheckLine) {
+		return content
+	}
+	partsContent := strings.Split(oldContent, CheckLine)
+	switch len(partsContent) {
+	case 2:
+		contentToSave := partsContent[1]
+		return content + CheckLine + contentToSave
+	case 3:
+		contentToSaveStart, contentToSaveEnd := partsContent[0], partsContent[2]
+		return contentToSaveStart + CheckLine + content + CheckLine + contentToSaveEnd
+	}
+	return content
+}
+
+func (c *confluence) UpdatePageByIdWithCheck(ctx context.Context, id string, content string, reCreate bool, opts ...UpdateOptions) error {
+	hashcode := hashContent(content)
 	versionInfo, err := c.GetVersionById(ctx, id)
 	if err != nil {
 		return err
@@ -228,7 +247,7 @@ func (c *confluence) UpdatePageByIdWithCheck(ctx context.Context, id string, con
 		return nil
 	}
 	content = fmt.Sprintf(HideHash, hashcode) + "\n" + content
-	return c.UpdatePageById(ctx, id, content, reCreate)
+	return c.UpdatePageById(ctx, id, content, reCreate, opts...)
 }
 
 func (c *confluence) updatePage(ctx context.Context, id string, req PageInfo) error {
@@ -238,25 +257,67 @@ func (c *confluence) updatePage(ctx context.Context, id string, req PageInfo) er
 		ContentType("application/json").
 		BasicAuth(c.user, c.password).
 		BodyJSON(req).
-		AddValidator(validateStatus).
+		AddValidator(validatePageUpdate).
 		Fetch(ctx)
 }
 
-func (c *confluence) UpdatePageParentById(ctx context.Context, id, parentPageId string) error {
-	versionInfo, err := c.GetVersionById(ctx, id)
+// updatePageWithRetry — обновление страницы с оптимистичной блокировкой. build получает актуальную версию
+// страницы и собирает запрос (false — обновлять не нужно); номер версии и заголовок проставляются здесь.
+// Если страницу успели изменить (409), она перечитывается и build вызывается заново
+func (c *confluence) updatePageWithRetry(ctx context.Context, id string, build func(page PageInfo) (PageInfo, bool, error), expand ...string) error {
+	for range maxUpdateAttempts {
+		page, err := c.GetPage(ctx, id, GetPageOptions{Expand: append([]string{ExpandVersion}, expand...)})
+		if err != nil {
+			return err
+		}
+		if page.Version == nil {
+			return fmt.Errorf("version of pageId %s is empty", id)
+		}
+		req, ok, err := build(page)
+		if err != nil || !ok {
+			return err
+		}
+		req.Id = id
+		req.Title = cmp.Or(req.Title, page.Title)
+		if req.Version == nil {
+			req.Version = &PageVersion{}
+		}
+		req.Version.Number = page.Version.Number + 1
+		err = c.updatePage(ctx, id, req)
+		if !errors.Is(err, ErrVersionConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: pageId %s, %d attempts", ErrVersionConflict, id, maxUpdateAttempts)
+}
+
+func (c *confluence) UpdatePageParentById(ctx context.Context, id, parentPageId string, opts ...UpdateOptions) error {
+	err := c.updatePageWithRetry(ctx, id, func(page PageInfo) (PageInfo, bool, error) {
+		return PageInfo{
+			Type:    "page",
+			Parents: []PageInfo{{Type: "page", Id: parentPageId}},
+			Version: mergeUpdateOptions(opts).version(),
+		}, true, nil
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("UpdatePageParentById — update confluence pageId %s, newParentId %s err: %w", id, parentPageId, err)
 	}
-	req := PageInfo{
-		Id:      id,
-		Type:    "page",
-		Title:   versionInfo.Title,
-		Version: &PageVersion{Number: versionInfo.Version.Number + 1},
-		Parents: []PageInfo{{Type: "page", Id: parentPageId}},
+	return nil
+}
+
+// DeletePage — удалить страницу в корзину пространства
+func (c *confluence) DeletePage(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id cannot be empty")
 	}
-	err = c.updatePage(ctx, id, req)
+	err := requests.
+		URL(fmt.Sprintf("%s/%s", c.baseUrl, id)).
+		Method(http.MethodDelete).
+		BasicAuth(c.user, c.password).
+		AddValidator(validateStatus).
+		Fetch(ctx)
 	if err != nil {
-		return fmt.Errorf("UpdatePageParentById — update confluence pageId %s, newParentId %s err: %w", id, parentPageId, err)
+		return fmt.Errorf("DeletePage — delete confluence pageId %s err: %w", id, err)
 	}
 	return nil
 }
@@ -277,67 +338,131 @@ func (c *confluence) AddLabelById(ctx context.Context, id, label string) error {
 	return nil
 }
 
+// GetLabelsById — названия всех меток страницы
 func (c *confluence) GetLabelsById(ctx context.Context, id string) ([]string, error) {
-	var resp labelResponse
-	err := requests.
-		URL(fmt.Sprintf("%s/%s/label", c.baseUrl, id)).
-		Method(http.MethodGet).
-		ContentType("application/json").
-		BasicAuth(c.user, c.password).
-		ToJSON(&resp).
-		AddValidator(validateStatus).
-		Fetch(ctx)
+	results, err := collectAll(paginate[Label](ctx, c, requests.URL(fmt.Sprintf("%s/%s/label", c.baseUrl, id)), defaultSearchLimit))
 	if err != nil {
 		return nil, fmt.Errorf("GetLabels — get confluence pageId %s labels err: %w", id, err)
 	}
 	var labels []string
-	for _, label := range resp.Results {
+	for _, label := range results {
 		labels = append(labels, label.Name)
 	}
 	return labels, nil
 }
 
-func (c *confluence) GetChildrenById(ctx context.Context, id string, limit int) ([]PageInfo, error) {
-	var resp searchPagesResponse
+// AddLabels — добавить несколько меток одним запросом
+func (c *confluence) AddLabels(ctx context.Context, id string, labels ...string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	req := make([]Label, 0, len(labels))
+	for _, label := range labels {
+		req = append(req, Label{Prefix: "global", Name: label})
+	}
 	err := requests.
-		URL(fmt.Sprintf("%s/%s/child/page", c.baseUrl, id)).
-		Method(http.MethodGet).
-		Param("limit", strconv.Itoa(limit)).
+		URL(fmt.Sprintf("%s/%s/label", c.baseUrl, id)).
+		Method(http.MethodPost).
 		ContentType("application/json").
 		BasicAuth(c.user, c.password).
-		ToJSON(&resp).
+		BodyJSON(req).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("GetChildrenById — get confluence pageId %s children err: %w", id, err)
+		return fmt.Errorf("AddLabels — update confluence pageId %s, labels %v err: %w", id, labels, err)
 	}
-	return resp.Results, nil
+	return nil
 }
 
-func (c *confluence) GetChildrenByIdRecursive(ctx context.Context, id string, limit int) ([]PageInfo, error) {
-	var children []PageInfo
-	childrenFirstLevel, err := c.GetChildrenById(ctx, id, limit)
+func (c *confluence) RemoveLabel(ctx context.Context, id, label string) error {
+	err := requests.
+		URL(fmt.Sprintf("%s/%s/label", c.baseUrl, id)).
+		Method(http.MethodDelete).
+		Param("name", label).
+		BasicAuth(c.user, c.password).
+		AddValidator(validateStatus).
+		Fetch(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("GetChildrenByIdRecursive — get confluence pageId %s children first level err: %w", id, err)
+		return fmt.Errorf("RemoveLabel — update confluence pageId %s, label %s err: %w", id, label, err)
 	}
-	for _, child := range childrenFirstLevel {
-		childrenNextLevel, err := c.GetChildrenById(ctx, child.Id, limit)
-		if err != nil {
-			return nil, fmt.Errorf("GetChildrenByIdRecursive — get confluence pageId %s children next level err: %w", id, err)
+	return nil
+}
+
+// SetLabels — оставить у страницы ровно указанные метки: недостающие добавляются одним запросом, лишние удаляются
+func (c *confluence) SetLabels(ctx context.Context, id string, labels []string) error {
+	current, err := c.GetLabelsById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("SetLabels — %w", err)
+	}
+	// Confluence хранит метки в нижнем регистре, поэтому разница считается по нормализованным меткам
+	desired, existing := normalizeLabels(labels), normalizeLabels(current)
+	var toAdd []string
+	for _, label := range desired {
+		if !slices.Contains(existing, label) {
+			toAdd = append(toAdd, label)
 		}
-		children = append(children, child)
-		if len(childrenNextLevel) == 0 {
+	}
+	if err := c.AddLabels(ctx, id, toAdd...); err != nil {
+		return fmt.Errorf("SetLabels — %w", err)
+	}
+	for _, label := range current {
+		if slices.Contains(desired, normalizeLabel(label)) {
 			continue
 		}
-		recursiveChildren, err := c.GetChildrenByIdRecursive(ctx, child.Id, limit)
-		if err != nil {
-			return nil, fmt.Errorf("GetChildrenByIdRecursive — get confluence pageId %s children recursive level err: %w", id, err)
+		if err := c.RemoveLabel(ctx, id, label); err != nil {
+			return fmt.Errorf("SetLabels — %w", err)
+		}
+	}
+	return nil
+}
+
+// normalizeLabel — метка в том виде, в котором её хранит Confluence
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.TrimSpace(label))
+}
+
+// normalizeLabels — нормализованные метки без пустых и повторов, порядок сохраняется
+func normalizeLabels(labels []string) []string {
+	res := make([]string, 0, len(labels))
+	for _, label := range labels {
+		if label = normalizeLabel(label); label != "" && !slices.Contains(res, label) {
+			res = append(res, label)
 		}
-		children = append(children, recursiveChildren...)
+	}
+	return res
+}
+
+// GetPagesByLabel — все страницы с меткой. Пустой spaceKey — поиск во всех пространствах
+func (c *confluence) GetPagesByLabel(ctx context.Context, spaceKey, label string) ([]PageInfo, error) {
+	cql := NewCQL().Type("page").Label(label)
+	if spaceKey != "" {
+		cql.Space(spaceKey)
+	}
+	pages, err := c.searchAllCQL(ctx, cql.String())
+	if err != nil {
+		return pages, fmt.Errorf("GetPagesByLabel — get confluence pages by label %s in space %s err: %w", label, spaceKey, err)
+	}
+	return pages, nil
+}
+
+// GetChildrenById — все дочерние страницы, limit — размер страницы запроса
+func (c *confluence) GetChildrenById(ctx context.Context, id string, limit int) ([]PageInfo, error) {
+	children, err := collectAll(paginate[PageInfo](ctx, c, requests.URL(fmt.Sprintf("%s/%s/child/page", c.baseUrl, id)), limit))
+	if err != nil {
+		return nil, fmt.Errorf("GetChildrenById — get confluence pageId %s children err: %w", id, err)
 	}
 	return children, nil
 }
 
+// GetChildrenByIdRecursive — все страницы под id плоским списком, каждая страница перед своими дочерними
+func (c *confluence) GetChildrenByIdRecursive(ctx context.Context, id string, limit int) ([]PageInfo, error) {
+	tree, err := c.GetPageTree(ctx, id, PageTreeOptions{PageSize: limit})
+	if err != nil {
+		return nil, fmt.Errorf("GetChildrenByIdRecursive — get confluence pageId %s tree err: %w", id, err)
+	}
+	return tree.Descendants(), nil
+}
+
 func (c *confluence) SetRestrictionUser(ctx context.Context, id, username, action string) error {
 	// метод работает только в экспериментальном апи, поэтому делаем подмену
 	baseUrl := strings.Replace(c.baseUrl, "/api/", "/experimental/", 1)
@@ -359,7 +484,7 @@ func (c *confluence) SetRestrictionGroup(ctx context.Context, id, groupName, act
 	// метод работает только в экспериментальном апи, поэтому делаем подмену
 	baseUrl := strings.Replace(c.baseUrl, "/api/", "/experimental/", 1)
 	err := requests.
-		URL(fmt.Sprintf("%s/%s/restriction/byOperation/%s/group/%s", baseUrl, id, action, groupName)).
+		URL(fmt.Sprintf("%s/%s/restriction/byOperation/%s/group/%s", baseUrl, id, action, url.PathEscape(groupName))).
 		Method(http.MethodPut).
 		ContentType("application/json").
 		BasicAuth(c.user, c.password).
@@ -371,20 +496,22 @@ func (c *confluence) SetRestrictionGroup(ctx context.Context, id, groupName, act
 	return nil
 }
 
+// SetRestrictionsForHFLabsOnly — добавить ограничения на чтение и изменение для себя и группы HFLabsWorkerGroup.
+// Остальные ограничения не трогаются; полный набор задаётся через ApplyRestrictions
 func (c *confluence) SetRestrictionsForHFLabsOnly(ctx context.Context, id string) error {
-	err := c.SetRestrictionUser(ctx, id, c.user, "update")
+	err := c.SetRestrictionUser(ctx, id, c.user, RestrictionUpdate)
 	if err != nil {
 		return err
 	}
-	err = c.SetRestrictionUser(ctx, id, c.user, "read")
+	err = c.SetRestrictionUser(ctx, id, c.user, RestrictionRead)
 	if err != nil {
 		return err
 	}
-	err = c.SetRestrictionGroup(ctx, id, "hfl-conf-worker", "update")
+	err = c.SetRestrictionGroup(ctx, id, HFLabsWorkerGroup, RestrictionUpdate)
 	if err != nil {
 		return err
 	}
-	err = c.SetRestrictionGroup(ctx, id, "hfl-conf-worker", "read")
+	err = c.SetRestrictionGroup(ctx, id, HFLabsWorkerGroup, RestrictionRead)
 	if err != nil {
 		return err
 	}
@@ -434,6 +561,7 @@ func (c *confluence) GetAttachments(ctx context.Context, pageID string) ([]Attac
 			Method(http.MethodGet).
 			Param("start", strconv.Itoa(start)).
 			Param("limit", strconv.Itoa(limit)).
+			Param("expand", ExpandVersion).
 			BasicAuth(c.user, c.password).
 			ToJSON(&resp).
 			AddValidator(validateStatus).
@@ -461,54 +589,57 @@ func (c *confluence) GetAttachments(ctx context.Context, pageID string) ([]Attac
 }
 
 func (c *confluence) DownloadAttachment(ctx context.Context, attachment Attachment) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.DownloadAttachmentTo(ctx, attachment, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DownloadAttachmentTo — записать содержимое вложения в w по мере скачивания, не загружая файл в память
+func (c *confluence) DownloadAttachmentTo(ctx context.Context, attachment Attachment, w io.Writer) error {
 	downloadURL, err := c.attachmentDownloadURL(attachment)
 	if err != nil {
-		return nil, fmt.Errorf("DownloadAttachment attachmentId %s title %s: %w", attachment.ID, attachment.Title, err)
+		return fmt.Errorf("DownloadAttachment attachmentId %s title %s: %w", attachment.ID, attachment.Title, err)
 	}
 
-	var buf bytes.Buffer
 	err = requests.
 		URL(downloadURL).
 		Method(http.MethodGet).
 		BasicAuth(c.user, c.password).
-		ToBytesBuffer(&buf).
+		ToWriter(w).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("DownloadAttachment attachmentId %s title %s: %w", attachment.ID, attachment.Title, err)
+		return fmt.Errorf("DownloadAttachment attachmentId %s title %s: %w", attachment.ID, attachment.Title, err)
 	}
-	return buf.Bytes(), nil
+	return nil
 }
 
+// CopyAttachments — скопировать вложения страницы на другую. Вложения с теми же размером и хешем пропускаются,
+// остальные загружаются без комментария и как незначительные изменения
 func (c *confluence) CopyAttachments(ctx context.Context, sourcePageID, targetPageID string) error {
-	sourceAttachments, err := c.GetAttachments(ctx, sourcePageID)
-	if err != nil {
-		return fmt.Errorf("CopyAttachments sourcePageId %s targetPageId %s: %w", sourcePageID, targetPageID, err)
-	}
-	targetAttachments, err := c.GetAttachments(ctx, targetPageID)
+	opts := SyncAttachmentsOptions{Concurrency: 1, MinorEdit: true, NoHash: true}
+	_, err := SyncAttachments(ctx, c, PageAttachments(c, sourcePageID), targetPageID, opts)
 	if err != nil {
 		return fmt.Errorf("CopyAttachments sourcePageId %s targetPageId %s: %w", sourcePageID, targetPageID, err)
 	}
+	return nil
+}
 
-	targetByTitle := make(map[string]Attachment, len(targetAttachments))
-	for _, attachment := range targetAttachments {
-		targetByTitle[attachment.Title] = attachment
+// DeleteAttachment — удалить вложение в корзину
+func (c *confluence) DeleteAttachment(ctx context.Context, attachmentID string) error {
+	if attachmentID == "" {
+		return fmt.Errorf("attachmentID cannot be empty")
 	}
-
-	for _, attachment := range sourceAttachments {
-		data, err := c.DownloadAttachment(ctx, attachment)
-		if err != nil {
-			return fmt.Errorf("CopyAttachments download sourcePageId %s attachmentId %s title %s: %w", sourcePageID, attachment.ID, attachment.Title, err)
-		}
-
-		if targetAttachment, ok := targetByTitle[attachment.Title]; ok {
-			err = c.UpdateAttachment(ctx, targetPageID, targetAttachment.ID, attachment.Title, data)
-		} else {
-			err = c.CreateAttachment(ctx, targetPageID, attachment.Title, data)
-		}
-		if err != nil {
-			return fmt.Errorf("CopyAttachments upload targetPageId %s title %s: %w", targetPageID, attachment.Title, err)
-		}
+	err := requests.
+		URL(fmt.Sprintf("%s/%s", c.baseUrl, attachmentID)).
+		Method(http.MethodDelete).
+		BasicAuth(c.user, c.password).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("DeleteAttachment attachmentId %s: %w", attachmentID, err)
 	}
 	return nil
 }
@@ -530,27 +661,66 @@ func (c *confluence) attachmentDownloadURL(attachment Attachment) (string, error
 }
 
 func (c *confluence) CreateAttachment(ctx context.Context, pageID, title string, data []byte) error {
-	return c.uploadAttachment(ctx, fmt.Sprintf("%s/%s/child/attachment", c.baseUrl, pageID), title, data)
+	return c.CreateAttachmentFrom(ctx, pageID, title, bytes.NewReader(data), AttachmentOptions{})
 }
 
 func (c *confluence) UpdateAttachment(ctx context.Context, pageID, attachmentID, title string, data []byte) error {
-	return c.uploadAttachment(ctx, fmt.Sprintf("%s/%s/child/attachment/%s/data", c.baseUrl, pageID, attachmentID), title, data)
+	return c.UpdateAttachmentFrom(ctx, pageID, attachmentID, title, bytes.NewReader(data), AttachmentOptions{})
+}
+
+// CreateAttachmentFrom — новое вложение из r. Файл передаётся потоком, без загрузки в память
+func (c *confluence) CreateAttachmentFrom(ctx context.Context, pageID, title string, r io.Reader, opts AttachmentOptions) error {
+	return c.uploadAttachment(ctx, fmt.Sprintf("%s/%s/child/attachment", c.baseUrl, pageID), title, r, opts)
 }
 
-func (c *confluence) uploadAttachment(ctx context.Context, uploadURL, title string, data []byte) error {
+// UpdateAttachmentFrom — новая версия вложения из r
+func (c *confluence) UpdateAttachmentFrom(ctx context.Context, pageID, attachmentID, title string, r io.Reader, opts AttachmentOptions) error {
+	return c.uploadAttachment(ctx, fmt.Sprintf("%s/%s/child/attachment/%s/data", c.baseUrl, pageID, attachmentID), title, r, opts)
+}
+
+func (c *confluence) uploadAttachment(ctx context.Context, uploadURL, title string, r io.Reader, opts AttachmentOptions) error {
+	mediaType, r := detectMediaType(title, r, opts.MediaType)
 	return requests.
 		URL(uploadURL).
 		Method(http.MethodPost).
 		Header("X-Atlassian-Token", "no-check").
 		Config(requests.BodyMultipart("", func(multi *multipart.Writer) error {
-			file, err := multi.CreateFormFile("file", title)
+			if opts.Comment != "" {
+				if err := multi.WriteField("comment", opts.Comment); err != nil {
+					return err
+				}
+			}
+			if err := multi.WriteField("minorEdit", strconv.FormatBool(opts.MinorEdit)); err != nil {
+				return err
+			}
+			header := make(textproto.MIMEHeader)
+			// Как в multipart.Writer.CreateFormFile: Confluence не понимает filename* для не-ASCII имён
+			header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, quoteEscaper.Replace(title)))
+			header.Set("Content-Type", mediaType)
+			file, err := multi.CreatePart(header)
 			if err != nil {
 				return err
 			}
-			_, err = io.Copy(file, bytes.NewReader(data))
+			_, err = io.Copy(file, r)
 			return err
 		})).
 		BasicAuth(c.user, c.password).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 }
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// detectMediaType — MIME-тип файла: заданный явно, по расширению или по первым байтам содержимого.
+// Прочитанные для определения байты возвращаются вместе с остатком r
+func detectMediaType(title string, r io.Reader, mediaType string) (string, io.Reader) {
+	if mediaType != "" {
+		return mediaType, r
+	}
+	if byExt := mime.TypeByExtension(filepath.Ext(title)); byExt != "" && byExt != "application/octet-stream" {
+		return byExt, r
+	}
+	buffered := bufio.NewReaderSize(r, 512)
+	head, _ := buffered.Peek(512)
+	return http.DetectContentType(head), buffered
+}
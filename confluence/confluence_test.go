@@ -0,0 +1,217 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPage(t *testing.T) {
+	var gotExpand string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/content/100", func(w http.ResponseWriter, r *http.Request) {
+		gotExpand = r.URL.Query().Get("expand")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"id": "100", "type": "page", "title": "Релиз 1.0",
+			"space": {"key": "DOC"},
+			"version": {"number": 7, "message": "обновлено", "minorEdit": true, "by": {"username": "automation"}},
+			"ancestors": [{"id": "1", "title": "Документация"}],
+			"body": {
+				"storage": {"value": "<p>текст</p>", "representation": "storage"},
+				"view": {"value": "<p>текст</p>", "representation": "view"},
+				"export_view": {"value": "<p>текст</p>", "representation": "export_view"}
+			},
+			"metadata": {"labels": {"results": [{"prefix": "global", "name": "generated"}, {"prefix": "global", "name": "release"}]}},
+			"_links": {"webui": "/display/DOC/Release", "base": "https://confluence.example.com"}
+		}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "user", "password")
+
+	t.Run("01. Все метаданные по умолчанию", func(t *testing.T) {
+		page, err := c.GetPage(context.Background(), "100", GetPageOptions{})
+		require.NoError(t, err)
+		require.Equal(t, "body.storage,version,ancestors,space,metadata.labels", gotExpand)
+		require.Equal(t, 7, page.Version.Number)
+		require.Equal(t, "automation", page.Version.By.Username)
+		require.Equal(t, "DOC", page.Space.Key)
+		require.Equal(t, "Документация", page.Parents[0].Title)
+		require.Equal(t, []string{"generated", "release"}, page.Labels())
+		require.Equal(t, "<p>текст</p>", page.Body.View.Value)
+		require.Equal(t, "export_view", page.Body.ExportView.Representation)
+		require.Equal(t, "/display/DOC/Release", page.Links.WebUI)
+	})
+
+	t.Run("02. Выбранные представления", func(t *testing.T) {
+		_, err := c.GetPage(context.Background(), "100", GetPageOptions{Expand: []string{ExpandBodyView, ExpandBodyExportView}})
+		require.NoError(t, err)
+		require.Equal(t, "body.view,body.export_view", gotExpand)
+	})
+
+	t.Run("03. Нет страницы", func(t *testing.T) {
+		_, err := c.GetPage(context.Background(), "404", GetPageOptions{})
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestGetPagesByName(t *testing.T) {
+	var queries []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/content", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		queries = append(queries, query.Encode())
+		start, _ := strconv.Atoi(query.Get("start"))
+		results := []PageInfo{{Id: strconv.Itoa(100 + start), Title: query.Get("title")}}
+		if start > 0 {
+			results = nil
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(pagedResponse[PageInfo]{Results: results, Limit: 1}))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "user", "password")
+
+	t.Run("01. Поиск в пространстве", func(t *testing.T) {
+		queries = nil
+		pages, err := c.GetPagesByName(context.Background(), "Релиз 1.0", "DOC")
+		require.NoError(t, err)
+		require.Equal(t, []PageInfo{{Id: "100", Title: "Релиз 1.0"}}, pages)
+		require.Equal(t, []string{
+			"limit=100&spaceKey=DOC&start=0&title=%D0%A0%D0%B5%D0%BB%D0%B8%D0%B7+1.0&type=page",
+			"limit=100&spaceKey=DOC&start=1&title=%D0%A0%D0%B5%D0%BB%D0%B8%D0%B7+1.0&type=page",
+		}, queries)
+	})
+
+	t.Run("02. Без пространства", func(t *testing.T) {
+		queries = nil
+		_, err := c.GetPagesByName(context.Background(), "Релиз 1.0", "")
+		require.NoError(t, err)
+		require.NotContains(t, queries[0], "spaceKey")
+	})
+}
+
+func TestUpdatePageByIdConflict(t *testing.T) {
+	// Между чтением и записью человек дописывает текст после линии, и первая запись получает 409
+	versions := []PageInfo{
+		{Title: "Релиз", Version: &PageVersion{Number: 5}, Body: &PageBody{Storage: PageStorage{Value: "<p>старое</p>" + CheckLine + "<p>заметки</p>"}}},
+		{Title: "Релиз", Version: &PageVersion{Number: 6}, Body: &PageBody{Storage: PageStorage{Value: "<p>старое</p>" + CheckLine + "<p>заметки и правки</p>"}}},
+	}
+	var gets, puts int
+	var conflicts int
+	var saved PageInfo
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/content/100", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			puts++
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&saved))
+			if puts <= conflicts {
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"message": "Version must be incremented on update"}`))
+			}
+			return
+		}
+		page := versions[min(gets, len(versions)-1)]
+		gets++
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(page))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "user", "password")
+
+	t.Run("01. Повтор после конфликта с новой версией страницы", func(t *testing.T) {
+		gets, puts, conflicts = 0, 0, 1
+		require.NoError(t, c.UpdatePageById(context.Background(), "100", "<p>новое</p>", false))
+		require.Equal(t, 2, puts)
+		require.Equal(t, 7, saved.Version.Number)
+		require.Equal(t, "<p>новое</p>"+CheckLine+"<p>заметки и правки</p>", saved.Body.Storage.Value)
+	})
+
+	t.Run("02. Попытки закончились", func(t *testing.T) {
+		gets, puts, conflicts = 0, 0, maxUpdateAttempts
+		err := c.UpdatePageParentById(context.Background(), "100", "1")
+		require.ErrorIs(t, err, ErrVersionConflict)
+		require.Equal(t, maxUpdateAttempts, puts)
+	})
+
+	t.Run("03. Комментарий к версии и незначительное изменение", func(t *testing.T) {
+		gets, puts, conflicts = 0, 0, 0
+		opts := UpdateOptions{Message: "Автоматическое обновление", MinorEdit: true}
+		require.NoError(t, c.UpdatePageById(context.Background(), "100", "<p>новое</p>", false, opts))
+		require.Equal(t, PageVersion{Number: 6, Message: "Автоматическое обновление", MinorEdit: true}, *saved.Version)
+
+		saved = PageInfo{}
+		require.NoError(t, c.UpdatePageParentById(context.Background(), "100", "1", UpdateOptions{MinorEdit: true}))
+		require.True(t, saved.Version.MinorEdit)
+		require.Empty(t, saved.Version.Message)
+	})
+}
+
+func TestLabels(t *testing.T) {
+	labels := []string{"generated", "manual", "old"}
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/content/100/label", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+			w.Header().Set("Content-Type", "application/json")
+			// Сервер отдаёт не больше двух меток за раз
+			resp := pagedResponse[Label]{Start: start, Limit: 2}
+			for _, name := range labels[min(start, len(labels)):min(start+2, len(labels))] {
+				resp.Results = append(resp.Results, Label{Prefix: "global", Name: name})
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(resp))
+		case http.MethodPost:
+			var req []Label
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			calls = append(calls, fmt.Sprintf("add %v", req))
+		case http.MethodDelete:
+			calls = append(calls, "remove "+r.URL.Query().Get("name"))
+		}
+	})
+	mux.HandleFunc("/rest/api/content/search", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.URL.Query().Get("cql"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results": [{"id": "100", "title": "API"}], "start": 0, "limit": 100, "size": 1}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "user", "password")
+	ctx := context.Background()
+
+	t.Run("01. Все метки постранично", func(t *testing.T) {
+		got, err := c.GetLabelsById(ctx, "100")
+		require.NoError(t, err)
+		require.Equal(t, labels, got)
+	})
+
+	t.Run("02. Установка меток по разнице", func(t *testing.T) {
+		calls = nil
+		require.NoError(t, c.SetLabels(ctx, "100", []string{"generated", "release", "api", "release"}))
+		require.Equal(t, []string{"add [{global release} {global api}]", "remove manual", "remove old"}, calls)
+	})
+
+	t.Run("03. Метки в разном регистре", func(t *testing.T) {
+		calls = nil
+		require.NoError(t, c.SetLabels(ctx, "100", []string{" Generated", "MANUAL", "Release ", "release", ""}))
+		require.Equal(t, []string{"add [{global release}]", "remove old"}, calls)
+	})
+
+	t.Run("04. Поиск по метке", func(t *testing.T) {
+		calls = nil
+		pages, err := c.GetPagesByLabel(ctx, "DOC", "generated")
+		require.NoError(t, err)
+		require.Len(t, pages, 1)
+		require.Equal(t, []string{`type = "page" AND label = "generated" AND space = "DOC"`}, calls)
+	})
+}
@@ -0,0 +1,132 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+const cqlTimeFormat = "2006/01/02 15:04"
+
+// CQL — построитель запроса Confluence Query Language. Условия объединяются через AND, значения экранируются
+//
+//	cql := confluence.NewCQL().Type("page").Space("DOC").Label("generated").String()
+type CQL struct {
+	clauses []string
+	orderBy string
+}
+
+func NewCQL() *CQL {
+	return &CQL{}
+}
+
+// QuoteCQL — строка в кавычках для подстановки в CQL
+func QuoteCQL(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func (q *CQL) add(field, op string, values ...string) *CQL {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, QuoteCQL(value))
+	}
+	switch {
+	case len(quoted) == 1:
+		q.clauses = append(q.clauses, fmt.Sprintf("%s %s %s", field, op, quoted[0]))
+	case len(quoted) > 1 && op == "=":
+		q.clauses = append(q.clauses, fmt.Sprintf("%s in (%s)", field, strings.Join(quoted, ", ")))
+	}
+	return q
+}
+
+// Type — тип содержимого: page, blogpost, attachment, comment
+func (q *CQL) Type(contentType string) *CQL { return q.add("type", "=", contentType) }
+
+func (q *CQL) Space(spaceKeys ...string) *CQL { return q.add("space", "=", spaceKeys...) }
+
+// Title — точное совпадение заголовка
+func (q *CQL) Title(title string) *CQL { return q.add("title", "=", title) }
+
+// TitleContains — поиск по словам заголовка
+func (q *CQL) TitleContains(text string) *CQL { return q.add("title", "~", text) }
+
+// Text — полнотекстовый поиск
+func (q *CQL) Text(text string) *CQL { return q.add("text", "~", text) }
+
+// Label — с любой из меток
+func (q *CQL) Label(labels ...string) *CQL { return q.add("label", "=", labels...) }
+
+// Ancestor — на любом уровне под страницей
+func (q *CQL) Ancestor(pageId string) *CQL { return q.add("ancestor", "=", pageId) }
+
+// Parent — непосредственно под страницей
+func (q *CQL) Parent(pageId string) *CQL { return q.add("parent", "=", pageId) }
+
+// Creator — создано пользователем (логин)
+func (q *CQL) Creator(username string) *CQL { return q.add("creator", "=", username) }
+
+func (q *CQL) LastModifiedAfter(t time.Time) *CQL {
+	return q.add("lastmodified", ">=", t.Format(cqlTimeFormat))
+}
+
+func (q *CQL) LastModifiedBefore(t time.Time) *CQL {
+	return q.add("lastmodified", "<", t.Format(cqlTimeFormat))
+}
+
+// Raw — условие CQL как есть, без экранирования
+func (q *CQL) Raw(clause string) *CQL {
+	q.clauses = append(q.clauses, clause)
+	return q
+}
+
+// OrderBy — сортировка, например OrderBy("lastmodified", true)
+func (q *CQL) OrderBy(field string, desc bool) *CQL {
+	q.orderBy = field
+	if desc {
+		q.orderBy += " desc"
+	}
+	return q
+}
+
+func (q *CQL) String() string {
+	res := strings.Join(q.clauses, " AND ")
+	if q.orderBy != "" {
+		res += " ORDER BY " + q.orderBy
+	}
+	return res
+}
+
+// SearchCQL — все результаты поиска по CQL постранично, limit — размер страницы.
+// Следующая страница берётся из _links.next, а если его нет — по start и limit
+func (c *confluence) SearchCQL(ctx context.Context, cql string, expand []string, limit int) iter.Seq2[PageInfo, error] {
+	return func(yield func(PageInfo, error) bool) {
+		if strings.TrimSpace(cql) == "" {
+			yield(PageInfo{}, fmt.Errorf("SearchCQL — cql cannot be empty"))
+			return
+		}
+		builder := requests.
+			URL(fmt.Sprintf("%s/search", c.baseUrl)).
+			Param("cql", cql)
+		if len(expand) > 0 {
+			builder.Param("expand", strings.Join(expand, ","))
+		}
+		for page, err := range paginate[PageInfo](ctx, c, builder, limit) {
+			if err != nil {
+				yield(PageInfo{}, fmt.Errorf("SearchCQL — search `%s` err: %w", cql, err))
+				return
+			}
+			if !yield(page, nil) {
+				return
+			}
+		}
+	}
+}
+
+// searchAllCQL — все результаты поиска одним списком
+func (c *confluence) searchAllCQL(ctx context.Context, cql string) ([]PageInfo, error) {
+	return collectAll(c.SearchCQL(ctx, cql, nil, defaultSearchLimit))
+}
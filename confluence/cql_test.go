@@ -0,0 +1,93 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCQL(t *testing.T) {
+	tests := []struct {
+		name string
+		cql  *CQL
+		want string
+	}{
+		{
+			name: "01. Экранирование кавычек",
+			cql:  NewCQL().Space("DOC").Type("page").TitleContains(`Отчёт "CDI" \ 2025`),
+			want: `space = "DOC" AND type = "page" AND title ~ "Отчёт \"CDI\" \\ 2025"`,
+		},
+		{
+			name: "02. Несколько меток, предок и дата",
+			cql: NewCQL().Label("generated", "release").Ancestor("100").Creator("automation").
+				LastModifiedAfter(time.Date(2025, 1, 2, 15, 4, 0, 0, time.UTC)).OrderBy("lastmodified", true),
+			want: `label in ("generated", "release") AND ancestor = "100" AND creator = "automation" ` +
+				`AND lastmodified >= "2025/01/02 15:04" ORDER BY lastmodified desc`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.cql.String())
+		})
+	}
+}
+
+func TestSearchCQL(t *testing.T) {
+	const total = 7
+	var srvUrl string
+	newHandler := func(withNext bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, `type = "page"`, r.URL.Query().Get("cql"))
+			start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			end := min(start+limit, total)
+			resp := map[string]any{"start": start, "limit": limit, "size": end - start}
+			var results []PageInfo
+			for i := start; i < end; i++ {
+				results = append(results, PageInfo{Id: strconv.Itoa(i + 1)})
+			}
+			resp["results"] = results
+			if withNext && end < total {
+				resp["_links"] = map[string]string{
+					"base": srvUrl + "/wiki",
+					"next": fmt.Sprintf("/rest/api/content/search?cql=%s&limit=%d&start=%d", url.QueryEscape(r.URL.Query().Get("cql")), limit, end),
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(resp))
+		}
+	}
+
+	for _, withNext := range []bool{true, false} {
+		t.Run(fmt.Sprintf("_links.next: %v", withNext), func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/wiki/rest/api/content/search", newHandler(withNext))
+			srv := httptest.NewServer(mux)
+			t.Cleanup(srv.Close)
+			srvUrl = srv.URL
+			c := NewConfluence(srv.URL+"/wiki/rest/api/content", "user", "password")
+
+			var ids []string
+			for page, err := range c.SearchCQL(context.Background(), NewCQL().Type("page").String(), nil, 3) {
+				require.NoError(t, err)
+				ids = append(ids, page.Id)
+			}
+			require.Equal(t, []string{"1", "2", "3", "4", "5", "6", "7"}, ids)
+
+			// Прерывание перебора не запрашивает следующие страницы
+			for page, err := range c.SearchCQL(context.Background(), NewCQL().Type("page").String(), nil, 3) {
+				require.NoError(t, err)
+				require.Equal(t, "1", page.Id)
+				break
+			}
+		})
+	}
+}
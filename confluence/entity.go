@@ -1,5 +1,7 @@
 package confluence
 
+import "cmp"
+
 type confluence struct {
 	user     string
 	password string
@@ -7,16 +9,53 @@ type confluence struct {
 }
 
 type Space struct {
-	Id   int    `json:"id,omitempty"`
-	Key  string `json:"key,omitempty"`
-	Name string `json:"name,omitempty"`
+	Id          int               `json:"id,omitempty"`
+	Key         string            `json:"key,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Type        string            `json:"type,omitempty"`   // global или personal
+	Status      string            `json:"status,omitempty"` // current или archived
+	Description *SpaceDescription `json:"description,omitempty"`
+	Homepage    *PageInfo         `json:"homepage,omitempty"`
+	Permissions []SpacePermission `json:"permissions,omitempty"`
+}
+
+type SpaceDescription struct {
+	Plain PageStorage `json:"plain"`
+}
+
+// SpacePermission — право на операцию в пространстве и кому оно выдано
+type SpacePermission struct {
+	Operation struct {
+		Operation  string `json:"operation"`  // read, create, delete, administer и т.д.
+		TargetType string `json:"targetType"` // space, page, blogpost, comment, attachment
+	} `json:"operation"`
+	Subjects         RestrictionList `json:"subjects"`
+	AnonymousAccess  bool            `json:"anonymousAccess"`
+	UnlicensedAccess bool            `json:"unlicensedAccess"`
+}
+
+// ListSpacesOptions — фильтры списка пространств
+type ListSpacesOptions struct {
+	Type   string   // global или personal, пусто — все
+	Labels []string // с любой из меток
+	Status string   // current или archived, пусто — все
+	Limit  int      // размер страницы запроса
 }
+
 type PageVersion struct {
-	Number int `json:"number,omitempty"`
+	Number    int    `json:"number,omitempty"`
+	When      string `json:"when,omitempty"`
+	Message   string `json:"message,omitempty"`
+	MinorEdit bool   `json:"minorEdit,omitempty"`
+	By        *User  `json:"by,omitempty"`
 }
 
+// PageBody — тело страницы в разных представлениях. Заполняются только запрошенные в expand
 type PageBody struct {
-	Storage PageStorage `json:"storage"`
+	Storage    PageStorage `json:"storage"`
+	View       PageStorage `json:"view,omitzero"`        // HTML для отображения
+	ExportView PageStorage `json:"export_view,omitzero"` // HTML без элементов интерфейса, с абсолютными ссылками
+	AtlasDoc   PageStorage `json:"atlas_doc_format,omitzero"`
 }
 
 type PageStorage struct {
@@ -29,39 +68,94 @@ type Label struct {
 	Name   string `json:"name,omitempty"`
 }
 
-type labelResponse struct {
-	Results    []Label `json:"results"`
-	TotalCount int     `json:"totalCount"`
-	StartIndex int     `json:"start"`
-	Limit      int     `json:"limit"`
-	Size       int     `json:"size"`
-}
 type VersionResponse struct {
 	Title   string `json:"title,omitempty"`
 	Version struct {
-		Number int `json:"number,omitempty"`
-		Editor struct {
+		Number    int    `json:"number,omitempty"`
+		When      string `json:"when,omitempty"`
+		Message   string `json:"message,omitempty"`
+		MinorEdit bool   `json:"minorEdit,omitempty"`
+		Editor    struct {
 			Username string `json:"username,omitempty"`
 		} `json:"by"`
 	} `json:"version"`
 }
 
-type searchPagesResponse struct {
-	Results []PageInfo `json:"results"`
-	Start   int        `json:"start,omitempty"`
-	Limit   int        `json:"limit,omitempty"`
-	Size    int        `json:"size,omitempty"`
+// pagedResponse — страница результатов постраничного запроса
+type pagedResponse[T any] struct {
+	Results []T `json:"results"`
+	Start   int `json:"start,omitempty"`
+	Limit   int `json:"limit,omitempty"`
+	Size    int `json:"size,omitempty"`
+	Links   struct {
+		Next string `json:"next,omitempty"`
+		Base string `json:"base,omitempty"`
+	} `json:"_links"`
 }
 
+type searchPagesResponse = pagedResponse[PageInfo]
+
 type PageInfo struct {
-	Status  string       `json:"status,omitempty"`
-	Type    string       `json:"type,omitempty"`
-	Title   string       `json:"title,omitempty"`
-	Id      string       `json:"id,omitempty"`
-	Space   *Space       `json:"space,omitempty"`
-	Version *PageVersion `json:"version,omitempty"`
-	Body    *PageBody    `json:"body,omitempty"`
-	Parents []PageInfo   `json:"ancestors,omitempty"`
+	Status   string        `json:"status,omitempty"`
+	Type     string        `json:"type,omitempty"`
+	Title    string        `json:"title,omitempty"`
+	Id       string        `json:"id,omitempty"`
+	Space    *Space        `json:"space,omitempty"`
+	Version  *PageVersion  `json:"version,omitempty"`
+	Body     *PageBody     `json:"body,omitempty"`
+	Parents  []PageInfo    `json:"ancestors,omitempty"`
+	Metadata *PageMetadata `json:"metadata,omitempty"`
+	Links    *PageLinks    `json:"_links,omitempty"`
+}
+
+// Labels — названия меток страницы, если они запрошены через expand metadata.labels
+func (p PageInfo) Labels() []string {
+	if p.Metadata == nil {
+		return nil
+	}
+	var labels []string
+	for _, label := range p.Metadata.Labels.Results {
+		labels = append(labels, label.Name)
+	}
+	return labels
+}
+
+type PageMetadata struct {
+	Labels struct {
+		Results []Label `json:"results"`
+	} `json:"labels"`
+}
+
+type PageLinks struct {
+	WebUI  string `json:"webui,omitempty"`
+	TinyUI string `json:"tinyui,omitempty"`
+	Base   string `json:"base,omitempty"`
+}
+
+// Значения expand для GetPage
+const (
+	ExpandBodyStorage    = "body.storage"
+	ExpandBodyView       = "body.view"
+	ExpandBodyExportView = "body.export_view"
+	ExpandBodyAtlasDoc   = "body.atlas_doc_format"
+	ExpandVersion        = "version"
+	ExpandAncestors      = "ancestors"
+	ExpandSpace          = "space"
+	ExpandLabels         = "metadata.labels"
+)
+
+// DefaultPageExpand — тело в формате хранения и все метаданные страницы
+var DefaultPageExpand = []string{ExpandBodyStorage, ExpandVersion, ExpandAncestors, ExpandSpace, ExpandLabels}
+
+// UpdateOptions — параметры новой версии страницы при обновлении
+type UpdateOptions struct {
+	Message   string // комментарий к версии, виден в истории страницы
+	MinorEdit bool   // незначительное изменение: наблюдатели не получают уведомление
+}
+
+// GetPageOptions — какие данные страницы запросить. Пустой Expand — DefaultPageExpand
+type GetPageOptions struct {
+	Expand []string
 }
 
 type User struct {
@@ -71,39 +165,65 @@ type User struct {
 	DisplayName string `json:"displayName"`
 }
 
+// Restrictions — ограничения страницы на чтение и изменение
 type Restrictions struct {
-	Read struct {
-		RestrictionByOperation
-	} `json:"read"`
-	Update struct {
-		RestrictionByOperation
-	} `json:"update"`
+	Read   RestrictionByOperation `json:"read"`
+	Update RestrictionByOperation `json:"update"`
 }
 
+// Операции, на которые ставятся ограничения
+const (
+	RestrictionRead   = "read"
+	RestrictionUpdate = "update"
+)
+
 type RestrictionByOperation struct {
-	Operation    string `json:"operation"`
-	Restrictions struct {
-		User struct {
-			Results []struct {
-				Type        string `json:"type"`
-				Username    string `json:"username"`
-				UserKey     string `json:"userKey"`
-				DisplayName string `json:"displayName"`
-			} `json:"results"`
-			Start int `json:"start"`
-			Limit int `json:"limit"`
-			Size  int `json:"size"`
-		} `json:"user"`
-		Group struct {
-			Results []struct {
-				Type string `json:"type"`
-				Name string `json:"name"`
-			} `json:"results"`
-			Start int `json:"start"`
-			Limit int `json:"limit"`
-			Size  int `json:"size"`
-		} `json:"group"`
-	} `json:"restrictions"`
+	Operation    string          `json:"operation"`
+	Restrictions RestrictionList `json:"restrictions"`
+}
+
+// RestrictionList — пользователи и группы, которым разрешена операция
+type RestrictionList struct {
+	User  RestrictionUsers  `json:"user"`
+	Group RestrictionGroups `json:"group"`
+}
+
+type RestrictionUsers struct {
+	Results []RestrictionUser `json:"results"`
+	Start   int               `json:"start"`
+	Limit   int               `json:"limit"`
+	Size    int               `json:"size"`
+}
+
+type RestrictionUser struct {
+	Type        string `json:"type"`
+	Username    string `json:"username"`
+	UserKey     string `json:"userKey"`
+	DisplayName string `json:"displayName"`
+}
+
+type RestrictionGroups struct {
+	Results []RestrictionGroup `json:"results"`
+	Start   int                `json:"start"`
+	Limit   int                `json:"limit"`
+	Size    int                `json:"size"`
+}
+
+type RestrictionGroup struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// RestrictionTargets — кому разрешена операция. Пустые списки — ограничения нет, операция доступна всем
+type RestrictionTargets struct {
+	Users  []string // логины
+	Groups []string
+}
+
+// DesiredRestrictions — полный набор ограничений страницы для ApplyRestrictions
+type DesiredRestrictions struct {
+	Read   RestrictionTargets
+	Update RestrictionTargets
 }
 
 type AttachmentResponse struct {
@@ -114,9 +234,40 @@ type AttachmentResponse struct {
 }
 
 type Attachment struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
-	Links struct {
+	ID         string               `json:"id"`
+	Title      string               `json:"title"`
+	Version    *PageVersion         `json:"version,omitempty"`
+	Metadata   AttachmentMetadata   `json:"metadata"`
+	Extensions AttachmentExtensions `json:"extensions"`
+	Links      struct {
 		Download string `json:"download"`
 	} `json:"_links"`
 }
+
+type AttachmentMetadata struct {
+	MediaType string `json:"mediaType,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+type AttachmentExtensions struct {
+	MediaType string `json:"mediaType,omitempty"`
+	FileSize  int64  `json:"fileSize,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+// Size — размер файла в байтах
+func (a Attachment) Size() int64 {
+	return a.Extensions.FileSize
+}
+
+// MediaType — MIME-тип файла
+func (a Attachment) MediaType() string {
+	return cmp.Or(a.Metadata.MediaType, a.Extensions.MediaType)
+}
+
+// AttachmentOptions — параметры загружаемой версии вложения
+type AttachmentOptions struct {
+	Comment   string // комментарий к версии вложения
+	MinorEdit bool   // не уведомлять наблюдателей страницы
+	MediaType string // MIME-тип, по умолчанию определяется по расширению и содержимому
+}
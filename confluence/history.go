@@ -0,0 +1,105 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// restoreVersionRequest — тело запроса восстановления версии
+type restoreVersionRequest struct {
+	OperationKey string `json:"operationKey"`
+	Params       struct {
+		VersionNumber int    `json:"versionNumber"`
+		Message       string `json:"message"`
+	} `json:"params"`
+}
+
+// PurgeTrashedPage — окончательно удалить страницу из корзины. Страница должна быть сначала удалена через DeletePage
+func (c *confluence) PurgeTrashedPage(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("id cannot be empty")
+	}
+	err := requests.
+		URL(fmt.Sprintf("%s/%s", c.baseUrl, id)).
+		Method(http.MethodDelete).
+		Param("status", "trashed").
+		BasicAuth(c.user, c.password).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("PurgeTrashedPage — purge confluence pageId %s err: %w", id, err)
+	}
+	return nil
+}
+
+// GetPageHistory — все версии страницы с автором, временем и комментарием, начиная с последней
+func (c *confluence) GetPageHistory(ctx context.Context, id string) ([]PageVersion, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id cannot be empty")
+	}
+	// метод работает только в экспериментальном апи, поэтому делаем подмену
+	baseUrl := strings.Replace(c.baseUrl, "/api/", "/experimental/", 1)
+	builder := requests.URL(fmt.Sprintf("%s/%s/version", baseUrl, id))
+	versions, err := collectAll(paginate[PageVersion](ctx, c, builder, defaultSearchLimit))
+	if err != nil {
+		return nil, fmt.Errorf("GetPageHistory — get confluence pageId %s history err: %w", id, err)
+	}
+	return versions, nil
+}
+
+// GetPageVersionContent — содержимое страницы в формате хранения на момент версии number
+func (c *confluence) GetPageVersionContent(ctx context.Context, id string, number int) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("id cannot be empty")
+	}
+	var resp PageInfo
+	err := requests.
+		URL(fmt.Sprintf("%s/%s", c.baseUrl, id)).
+		Method(http.MethodGet).
+		Param("status", "historical").
+		Param("version", strconv.Itoa(number)).
+		Param("expand", strings.Join([]string{ExpandBodyStorage, ExpandVersion}, ",")).
+		BasicAuth(c.user, c.password).
+		ToJSON(&resp).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("GetPageVersionContent — get confluence pageId %s version %d err: %w", id, number, err)
+	}
+	if resp.Body == nil {
+		return "", nil
+	}
+	return resp.Body.Storage.Value, nil
+}
+
+// RestoreVersion — сделать содержимое версии number новой текущей версией страницы
+func (c *confluence) RestoreVersion(ctx context.Context, id string, number int) error {
+	if id == "" {
+		return fmt.Errorf("id cannot be empty")
+	}
+	if number <= 0 {
+		return fmt.Errorf("version number must be positive")
+	}
+	req := restoreVersionRequest{OperationKey: "restore"}
+	req.Params.VersionNumber = number
+	req.Params.Message = fmt.Sprintf("Restored version %d", number)
+	// метод работает только в экспериментальном апи, поэтому делаем подмену
+	baseUrl := strings.Replace(c.baseUrl, "/api/", "/experimental/", 1)
+	err := requests.
+		URL(fmt.Sprintf("%s/%s/version", baseUrl, id)).
+		Method(http.MethodPost).
+		BodyJSON(req).
+		ContentType("application/json").
+		BasicAuth(c.user, c.password).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("RestoreVersion — restore confluence pageId %s version %d err: %w", id, number, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageHistory(t *testing.T) {
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE /rest/api/content/10", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "delete status="+r.URL.Query().Get("status"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /rest/experimental/content/10/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results": [
+			{"number": 3, "when": "2025-03-01T10:00:00.000+03:00", "message": "плохая генерация", "by": {"username": "automation"}},
+			{"number": 2, "when": "2025-02-01T10:00:00.000+03:00", "minorEdit": true, "by": {"username": "ivanov"}}
+		], "start": 0, "limit": 100, "size": 2}`))
+	})
+	mux.HandleFunc("GET /rest/api/content/10", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "historical", r.URL.Query().Get("status"))
+		require.Equal(t, "2", r.URL.Query().Get("version"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "10", "version": {"number": 2}, "body": {"storage": {"value": "<p>v2</p>", "representation": "storage"}}}`))
+	})
+	mux.HandleFunc("POST /rest/experimental/content/10/version", func(w http.ResponseWriter, r *http.Request) {
+		var req restoreVersionRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		calls = append(calls, req.OperationKey+" "+req.Params.Message)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"number": 4}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "user", "password")
+	ctx := context.Background()
+
+	t.Run("01. История версий", func(t *testing.T) {
+		versions, err := c.GetPageHistory(ctx, "10")
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		require.Equal(t, "плохая генерация", versions[0].Message)
+		require.Equal(t, "automation", versions[0].By.Username)
+		require.True(t, versions[1].MinorEdit)
+	})
+
+	t.Run("02. Содержимое и восстановление версии", func(t *testing.T) {
+		content, err := c.GetPageVersionContent(ctx, "10", 2)
+		require.NoError(t, err)
+		require.Equal(t, "<p>v2</p>", content)
+		require.NoError(t, c.RestoreVersion(ctx, "10", 2))
+		require.Error(t, c.RestoreVersion(ctx, "10", 0))
+	})
+
+	t.Run("03. Удаление из корзины", func(t *testing.T) {
+		require.NoError(t, c.PurgeTrashedPage(ctx, "10"))
+		require.Equal(t, []string{"restore Restored version 2", "delete status=trashed"}, calls)
+	})
+}
@@ -1,40 +1,74 @@
 package confluence
 
-import "context"
+import (
+	"context"
+	"io"
+	"iter"
+)
 
 type ApiConfluence interface {
 	GetContentById(ctx context.Context, id string) (string, error)
+	GetPage(ctx context.Context, id string, opts GetPageOptions) (PageInfo, error)
 	GetVersionById(ctx context.Context, id string) (VersionResponse, error)
 	GetPagesByName(ctx context.Context, name, spaceKey string) ([]PageInfo, error)
 	GetPagesByIncludedName(ctx context.Context, name, spaceKey string) ([]PageInfo, error)
 	GetChildPageByName(ctx context.Context, parentPageId, name string) (PageInfo, bool, error)
+	SearchCQL(ctx context.Context, cql string, expand []string, limit int) iter.Seq2[PageInfo, error]
+
+	GetSpace(ctx context.Context, key string) (Space, error)
+	ListSpaces(ctx context.Context, opts ListSpacesOptions) ([]Space, error)
+	CreateSpace(ctx context.Context, key, name, description string) (Space, error)
+	GetSpaceHomepage(ctx context.Context, key string) (PageInfo, error)
+	GetSpacePermissions(ctx context.Context, key string) ([]SpacePermission, error)
 
 	GetUserByUsername(ctx context.Context, username string) (*User, error)
 	GetUserByKey(ctx context.Context, key string) (*User, error)
 
 	GetChildrenById(ctx context.Context, id string, limit int) ([]PageInfo, error)
 	GetChildrenByIdRecursive(ctx context.Context, id string, limit int) ([]PageInfo, error)
+	GetPageTree(ctx context.Context, id string, opts PageTreeOptions) (*PageTree, error)
+	GetPageTreeByDescendants(ctx context.Context, id string, pageSize int) (*PageTree, error)
 
 	CreatePage(ctx context.Context, name, spaceKey, content string, parentPageId string) (string, error)
 	CreatePageWithHash(ctx context.Context, name, spaceKey, content, parentPageId string) (string, error)
+	DeletePage(ctx context.Context, id string) error
+	PurgeTrashedPage(ctx context.Context, id string) error
+
+	GetPageHistory(ctx context.Context, id string) ([]PageVersion, error)
+	GetPageVersionContent(ctx context.Context, id string, number int) (string, error)
+	RestoreVersion(ctx context.Context, id string, number int) error
 
 	AddLabelById(ctx context.Context, id, label string) error
 	GetLabelsById(ctx context.Context, id string) ([]string, error)
+	AddLabels(ctx context.Context, id string, labels ...string) error
+	RemoveLabel(ctx context.Context, id, label string) error
+	SetLabels(ctx context.Context, id string, labels []string) error
+	GetPagesByLabel(ctx context.Context, spaceKey, label string) ([]PageInfo, error)
 
-	UpdatePageById(ctx context.Context, id string, content string, reCreate bool) error
-	UpdatePageByIdWithCheck(ctx context.Context, id string, content string, reCreate bool) error
-	UpdatePageParentById(ctx context.Context, id, parentId string) error
+	UpdatePageById(ctx context.Context, id string, content string, reCreate bool, opts ...UpdateOptions) error
+	UpdatePageByIdWithCheck(ctx context.Context, id string, content string, reCreate bool, opts ...UpdateOptions) error
+	UpdatePageParentById(ctx context.Context, id, parentId string, opts ...UpdateOptions) error
+	UpdateSection(ctx context.Context, id, name, content string) error
+	UpdateSections(ctx context.Context, id string, sections ...Section) error
 
 	SetRestrictionUser(ctx context.Context, id, username, action string) error
 	SetRestrictionGroup(ctx context.Context, id, groupName, action string) error
 	SetRestrictionsForHFLabsOnly(ctx context.Context, id string) error
+	RemoveRestrictionUser(ctx context.Context, id, username, action string) error
+	RemoveRestrictionGroup(ctx context.Context, id, groupName, action string) error
+	ApplyRestrictions(ctx context.Context, id string, desired DesiredRestrictions) error
+	ApplyRestrictionsRecursive(ctx context.Context, id string, desired DesiredRestrictions) error
 
 	GetRestrictionsById(ctx context.Context, id string) (Restrictions, error)
 	GetRestrictionByOperationById(ctx context.Context, id, operation string) (RestrictionByOperation, error)
 
 	GetAttachments(ctx context.Context, pageID string) ([]Attachment, error)
 	DownloadAttachment(ctx context.Context, attachment Attachment) ([]byte, error)
+	DownloadAttachmentTo(ctx context.Context, attachment Attachment, w io.Writer) error
 	CreateAttachment(ctx context.Context, pageID, title string, data []byte) error
+	CreateAttachmentFrom(ctx context.Context, pageID, title string, r io.Reader, opts AttachmentOptions) error
 	UpdateAttachment(ctx context.Context, pageID, attachmentID, title string, data []byte) error
+	UpdateAttachmentFrom(ctx context.Context, pageID, attachmentID, title string, r io.Reader, opts AttachmentOptions) error
+	DeleteAttachment(ctx context.Context, attachmentID string) error
 	CopyAttachments(ctx context.Context, sourcePageID, targetPageID string) error
 }
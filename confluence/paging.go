@@ -0,0 +1,96 @@
+package confluence
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+const defaultSearchLimit = 100
+
+// paginate — все результаты постраничного запроса. first — запрос первой страницы без start и limit.
+// Следующая страница берётся из _links.next, а если его нет — по start и limit
+func paginate[T any](ctx context.Context, c *confluence, first *requests.Builder, limit int) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if limit <= 0 {
+			limit = defaultSearchLimit
+		}
+		var next string
+		for start := 0; ; {
+			builder := first.Clone().
+				Param("start", strconv.Itoa(start)).
+				Param("limit", strconv.Itoa(limit))
+			if next != "" {
+				builder = requests.URL(next)
+			}
+			var resp pagedResponse[T]
+			err := builder.
+				Method(http.MethodGet).
+				BasicAuth(c.user, c.password).
+				ToJSON(&resp).
+				AddValidator(validateStatus).
+				Fetch(ctx)
+			if err != nil {
+				yield(zero, fmt.Errorf("start %d: %w", start, err))
+				return
+			}
+			for _, page := range resp.Results {
+				if !yield(page, nil) {
+					return
+				}
+			}
+			if len(resp.Results) == 0 {
+				return
+			}
+			start += len(resp.Results)
+			if resp.Links.Next != "" {
+				if next, err = c.nextSearchUrl(resp.Links.Base, resp.Links.Next); err != nil {
+					yield(zero, fmt.Errorf("next link %s: %w", resp.Links.Next, err))
+					return
+				}
+				continue
+			}
+			if next != "" || len(resp.Results) < cmp.Or(resp.Limit, limit) {
+				// Confluence с поддержкой _links.next не отдаёт ссылку на последней странице
+				return
+			}
+		}
+	}
+}
+
+// nextSearchUrl — абсолютная ссылка на следующую страницу результатов. Ссылка _links.next
+// относительна _links.base (адрес Confluence вместе с контекстом), иначе — хоста из baseUrl
+func (c *confluence) nextSearchUrl(base, next string) (string, error) {
+	if base != "" {
+		return strings.TrimRight(base, "/") + next, nil
+	}
+	baseUrl, err := url.Parse(c.baseUrl)
+	if err != nil {
+		return "", err
+	}
+	nextUrl, err := url.Parse(next)
+	if err != nil {
+		return "", err
+	}
+	return baseUrl.ResolveReference(nextUrl).String(), nil
+}
+
+// collectAll — все результаты постраничного запроса одним списком
+func collectAll[T any](items iter.Seq2[T, error]) ([]T, error) {
+	var res []T
+	for item, err := range items {
+		if err != nil {
+			return res, err
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
@@ -0,0 +1,174 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// HFLabsWorkerGroup — группа сотрудников HFLabs для SetRestrictionsForHFLabsOnly
+const HFLabsWorkerGroup = "hfl-conf-worker"
+
+// Targets — логины пользователей и названия групп из ограничения
+func (l RestrictionList) Targets() RestrictionTargets {
+	var targets RestrictionTargets
+	for _, user := range l.User.Results {
+		targets.Users = append(targets.Users, user.Username)
+	}
+	for _, group := range l.Group.Results {
+		targets.Groups = append(targets.Groups, group.Name)
+	}
+	return targets
+}
+
+func (c *confluence) RemoveRestrictionUser(ctx context.Context, id, username, action string) error {
+	// метод работает только в экспериментальном апи, поэтому делаем подмену
+	baseUrl := strings.Replace(c.baseUrl, "/api/", "/experimental/", 1)
+	err := requests.
+		URL(fmt.Sprintf("%s/%s/restriction/byOperation/%s/user", baseUrl, id, action)).
+		Method(http.MethodDelete).
+		Param("userName", username).
+		BasicAuth(c.user, c.password).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("RemoveRestrictionUser — remove restriction '%s' with user '%s' on pageId %s err: %w", action, username, id, err)
+	}
+	return nil
+}
+
+func (c *confluence) RemoveRestrictionGroup(ctx context.Context, id, groupName, action string) error {
+	// метод работает только в экспериментальном апи, поэтому делаем подмену
+	baseUrl := strings.Replace(c.baseUrl, "/api/", "/experimental/", 1)
+	err := requests.
+		URL(fmt.Sprintf("%s/%s/restriction/byOperation/%s/group/%s", baseUrl, id, action, url.PathEscape(groupName))).
+		Method(http.MethodDelete).
+		BasicAuth(c.user, c.password).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("RemoveRestrictionGroup — remove restriction '%s' with group '%s' on pageId %s err: %w", action, groupName, id, err)
+	}
+	return nil
+}
+
+// hasMore — вернул ли Confluence полную страницу пользователей или групп, т.е. могут быть следующие
+func (l RestrictionList) hasMore() bool {
+	return l.User.Limit > 0 && l.User.Size >= l.User.Limit || l.Group.Limit > 0 && l.Group.Size >= l.Group.Limit
+}
+
+// allRestrictionTargets — все пользователи и группы ограничения операции. Списки в ответе постраничные,
+// поэтому, начиная с уже полученной страницы list, следующие дочитываются по start/limit
+func (c *confluence) allRestrictionTargets(ctx context.Context, id, operation string, list RestrictionList) (RestrictionTargets, error) {
+	targets := list.Targets()
+	for list.hasMore() {
+		limit := max(list.User.Limit, list.Group.Limit)
+		start := max(list.User.Start, list.Group.Start) + limit
+		var next RestrictionByOperation
+		err := requests.
+			URL(fmt.Sprintf("%s/%s/restriction/byOperation/%s", c.baseUrl, id, operation)).
+			Method(http.MethodGet).
+			Param("start", strconv.Itoa(start)).
+			Param("limit", strconv.Itoa(limit)).
+			ContentType("application/json").
+			BasicAuth(c.user, c.password).
+			ToJSON(&next).
+			AddValidator(validateStatus).
+			Fetch(ctx)
+		if err != nil {
+			return targets, fmt.Errorf("allRestrictionTargets — get confluence pageId %s '%s' restrictions from %d err: %w", id, operation, start, err)
+		}
+		list = next.Restrictions
+		page := list.Targets()
+		targets.Users = append(targets.Users, page.Users...)
+		targets.Groups = append(targets.Groups, page.Groups...)
+	}
+	return targets, nil
+}
+
+// ApplyRestrictions — привести ограничения страницы к desired: недостающие пользователи и группы добавляются,
+// лишние удаляются. Сначала добавление, чтобы страница ни на момент не оставалась открытой для всех.
+// Если операция ограничивается, среди её пользователей должен быть сам клиент — иначе он потеряет доступ к странице
+func (c *confluence) ApplyRestrictions(ctx context.Context, id string, desired DesiredRestrictions) error {
+	operations := []struct {
+		action        string
+		want, current RestrictionTargets
+	}{
+		{action: RestrictionRead, want: desired.Read},
+		{action: RestrictionUpdate, want: desired.Update},
+	}
+	for _, op := range operations {
+		restricted := len(op.want.Users) > 0 || len(op.want.Groups) > 0
+		if restricted && !slices.Contains(op.want.Users, c.user) {
+			return fmt.Errorf("ApplyRestrictions — desired '%s' restriction on pageId %s must include user '%s'", op.action, id, c.user)
+		}
+	}
+	current, err := c.GetRestrictionsById(ctx, id)
+	if err != nil {
+		return fmt.Errorf("ApplyRestrictions — %w", err)
+	}
+	for i, list := range []RestrictionList{current.Read.Restrictions, current.Update.Restrictions} {
+		if operations[i].current, err = c.allRestrictionTargets(ctx, id, operations[i].action, list); err != nil {
+			return fmt.Errorf("ApplyRestrictions — %w", err)
+		}
+	}
+	for _, op := range operations {
+		for _, username := range missing(op.want.Users, op.current.Users) {
+			if err := c.SetRestrictionUser(ctx, id, username, op.action); err != nil {
+				return fmt.Errorf("ApplyRestrictions — %w", err)
+			}
+		}
+		for _, group := range missing(op.want.Groups, op.current.Groups) {
+			if err := c.SetRestrictionGroup(ctx, id, group, op.action); err != nil {
+				return fmt.Errorf("ApplyRestrictions — %w", err)
+			}
+		}
+	}
+	for _, op := range operations {
+		for _, username := range missing(op.current.Users, op.want.Users) {
+			if err := c.RemoveRestrictionUser(ctx, id, username, op.action); err != nil {
+				return fmt.Errorf("ApplyRestrictions — %w", err)
+			}
+		}
+		for _, group := range missing(op.current.Groups, op.want.Groups) {
+			if err := c.RemoveRestrictionGroup(ctx, id, group, op.action); err != nil {
+				return fmt.Errorf("ApplyRestrictions — %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyRestrictionsRecursive — ApplyRestrictions для страницы и всех страниц под ней
+func (c *confluence) ApplyRestrictionsRecursive(ctx context.Context, id string, desired DesiredRestrictions) error {
+	descendants, err := c.GetChildrenByIdRecursive(ctx, id, defaultSearchLimit)
+	if err != nil {
+		return fmt.Errorf("ApplyRestrictionsRecursive — %w", err)
+	}
+	if err := c.ApplyRestrictions(ctx, id, desired); err != nil {
+		return fmt.Errorf("ApplyRestrictionsRecursive — %w", err)
+	}
+	for _, page := range descendants {
+		if err := c.ApplyRestrictions(ctx, page.Id, desired); err != nil {
+			return fmt.Errorf("ApplyRestrictionsRecursive — %w", err)
+		}
+	}
+	return nil
+}
+
+// missing — значения из want, которых нет в have, без повторов
+func missing(want, have []string) []string {
+	var res []string
+	for _, value := range want {
+		if !slices.Contains(have, value) && !slices.Contains(res, value) {
+			res = append(res, value)
+		}
+	}
+	return res
+}
@@ -0,0 +1,113 @@
+package confluence
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRestrictions(t *testing.T) {
+	var calls []string
+	var lastPath string
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/content/{id}/restriction/byOperation", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"read": {"operation": "read", "restrictions": {
+				"user": {"results": [{"type": "known", "username": "automation"}, {"type": "known", "username": "ivanov"}], "start": 0, "limit": 2, "size": 2},
+				"group": {"results": [], "start": 0, "limit": 2, "size": 0}
+			}},
+			"update": {"operation": "update", "restrictions": {
+				"user": {"results": []},
+				"group": {"results": [{"type": "group", "name": "confluence-users"}]}
+			}}
+		}`))
+	})
+	mux.HandleFunc("GET /rest/api/content/{id}/restriction/byOperation/read", func(w http.ResponseWriter, r *http.Request) {
+		// Вторая страница пользователей, на которой список заканчивается
+		require.Equal(t, "2", r.URL.Query().Get("start"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"operation": "read", "restrictions": {
+			"user": {"results": [{"type": "known", "username": "petrov"}], "start": 2, "limit": 2, "size": 1},
+			"group": {"results": [], "start": 2, "limit": 2, "size": 0}
+		}}`))
+	})
+	mux.HandleFunc("GET /rest/api/content/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "` + r.PathValue("id") + `"}`))
+	})
+	mux.HandleFunc("GET /rest/api/content/{id}/child/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.PathValue("id") == "10" {
+			_, _ = w.Write([]byte(`{"results": [{"id": "11"}], "start": 0, "limit": 100, "size": 1}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results": [], "start": 0, "limit": 100, "size": 0}`))
+	})
+	mux.HandleFunc("/rest/experimental/content/{id}/restriction/byOperation/{rest...}", func(w http.ResponseWriter, r *http.Request) {
+		call := strings.Join([]string{r.Method, r.PathValue("id"), r.PathValue("rest")}, " ")
+		if username := r.URL.Query().Get("userName"); username != "" {
+			call += "/" + username
+		}
+		calls = append(calls, call)
+		lastPath = r.URL.EscapedPath()
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "automation", "password")
+	desired := DesiredRestrictions{
+		Read:   RestrictionTargets{Users: []string{"automation"}, Groups: []string{HFLabsWorkerGroup}},
+		Update: RestrictionTargets{Users: []string{"automation"}},
+	}
+
+	t.Run("01. Текущие ограничения", func(t *testing.T) {
+		restrictions, err := c.GetRestrictionsById(context.Background(), "10")
+		require.NoError(t, err)
+		require.Equal(t, RestrictionTargets{Users: []string{"automation", "ivanov"}}, restrictions.Read.Restrictions.Targets())
+		require.Equal(t, "confluence-users", restrictions.Update.Restrictions.Group.Results[0].Name)
+	})
+
+	t.Run("02. Сначала добавление, потом удаление лишнего", func(t *testing.T) {
+		calls = nil
+		require.NoError(t, c.ApplyRestrictions(context.Background(), "10", desired))
+		require.Equal(t, []string{
+			"PUT 10 read/group/hfl-conf-worker",
+			"PUT 10 update/user/automation",
+			"DELETE 10 read/user/ivanov",
+			"DELETE 10 read/user/petrov",
+			"DELETE 10 update/group/confluence-users",
+		}, calls)
+	})
+
+	t.Run("03. Рекурсивно для поддерева", func(t *testing.T) {
+		calls = nil
+		require.NoError(t, c.ApplyRestrictionsRecursive(context.Background(), "10", desired))
+		require.Len(t, calls, 10)
+		require.Equal(t, "PUT 11 read/group/hfl-conf-worker", calls[5])
+	})
+
+	t.Run("04. Без себя в ограничениях", func(t *testing.T) {
+		calls = nil
+		err := c.ApplyRestrictions(context.Background(), "10", DesiredRestrictions{
+			Update: RestrictionTargets{Groups: []string{"Отдел разработки"}},
+		})
+		require.ErrorContains(t, err, "must include user 'automation'")
+		require.Empty(t, calls)
+
+		// Операции проверяются по порядку: сначала чтение, потом изменение
+		restricted := RestrictionTargets{Groups: []string{"Отдел разработки"}}
+		err = c.ApplyRestrictions(context.Background(), "10", DesiredRestrictions{Read: restricted, Update: restricted})
+		require.ErrorContains(t, err, "desired 'read' restriction")
+	})
+
+	t.Run("05. Название группы экранируется", func(t *testing.T) {
+		calls = nil
+		require.NoError(t, c.SetRestrictionGroup(context.Background(), "10", "qa/dev", RestrictionRead))
+		require.Equal(t, []string{"PUT 10 read/group/qa/dev"}, calls)
+		require.Equal(t, "/rest/experimental/content/10/restriction/byOperation/read/group/qa%2Fdev", lastPath)
+	})
+}
@@ -0,0 +1,204 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	sectionParam    = "section"
+	sectionEndParam = "section-end"
+)
+
+// Section — автоматически обновляемый раздел страницы
+type Section struct {
+	Name    string
+	Content string // содержимое в формате хранения
+}
+
+// RenderSection — раздел вместе с невидимыми границами, например для первичного создания страницы
+func RenderSection(name, content string) string {
+	return fmt.Sprintf(SectionStart, Escape(name), hashContent(content)) + content + fmt.Sprintf(SectionEnd, Escape(name))
+}
+
+// UpdateSection — заменить содержимое раздела name, не трогая остальную страницу.
+// Раздела нет — он добавляется в конец страницы. Хеш содержимого хранится в начале раздела,
+// поэтому страница не обновляется, если раздел не изменился. Как и в UpdatePageByIdWithCheck,
+// хешу верим только если последним страницу правил automation, иначе сравнивается текущее содержимое раздела
+func (c *confluence) UpdateSection(ctx context.Context, id, name, content string) error {
+	return c.UpdateSections(ctx, id, Section{Name: name, Content: content})
+}
+
+// UpdateSections — заменить несколько разделов страницы одной новой версией
+func (c *confluence) UpdateSections(ctx context.Context, id string, sections ...Section) error {
+	err := c.updatePageWithRetry(ctx, id, func(page PageInfo) (PageInfo, bool, error) {
+		var current string
+		if page.Body != nil {
+			current = page.Body.Storage.Value
+		}
+		trusted := page.Version.By != nil && page.Version.By.Username == "automation"
+		updated, changed, err := applySections(current, sections, trusted)
+		if err != nil || !changed {
+			return PageInfo{}, false, err
+		}
+		return PageInfo{Type: "page", Body: &PageBody{Storage: PageStorage{Value: updated, Representation: "storage"}}}, true, nil
+	}, ExpandBodyStorage)
+	if err != nil {
+		return fmt.Errorf("UpdateSections — update confluence pageId %s err: %w", id, err)
+	}
+	return nil
+}
+
+// applySections — подставить разделы в документ. changed — изменился ли хотя бы один раздел.
+// trusted — последнюю версию записала автоматизация, и хешу в начале раздела можно верить без
+// сравнения с текущим содержимым
+func applySections(content string, sections []Section, trusted bool) (string, bool, error) {
+	doc, err := ParseStorage(content)
+	if err != nil {
+		return "", false, err
+	}
+	changed := false
+	for _, section := range sections {
+		if strings.TrimSpace(section.Name) == "" {
+			return "", false, fmt.Errorf("section name cannot be empty")
+		}
+		hashcode := hashContent(section.Content)
+		starts := doc.MacrosByParam("hfl", sectionParam, section.Name)
+		if len(starts) == 0 {
+			if err := doc.appendXHTML(RenderSection(section.Name, section.Content)); err != nil {
+				return "", false, err
+			}
+			changed = true
+			continue
+		}
+		start := starts[0]
+		end := doc.sectionEnd(start, section.Name)
+		if end == nil {
+			return "", false, fmt.Errorf("end of section %q not found", section.Name)
+		}
+		anchor, between := nodesBetween(start, end)
+		if body := start.MacroBody(); body != nil && strings.TrimSpace(body.Text()) == hashcode {
+			if trusted || hashContent(strings.TrimSpace(renderNodes(between))) == hashContent(strings.TrimSpace(section.Content)) {
+				continue
+			}
+		}
+		for _, node := range between {
+			node.detach()
+		}
+		if err := anchor.insertAfter("\n" + section.Content + "\n"); err != nil {
+			return "", false, err
+		}
+		if err := start.SetMacroBody("\n<p>" + hashcode + "</p>\n"); err != nil {
+			return "", false, err
+		}
+		changed = true
+	}
+	return doc.String(), changed, nil
+}
+
+// appendXHTML — дописать разобранный фрагмент в конец узла
+func (n *StorageNode) appendXHTML(xhtml string) error {
+	fragment, err := ParseStorage(xhtml)
+	if err != nil {
+		return err
+	}
+	for _, child := range fragment.Children {
+		n.appendChild(child)
+	}
+	return nil
+}
+
+// sectionEnd — первый после start в порядке документа макрос конца раздела name.
+// Редактор Confluence может обернуть границы раздела в абзацы, поэтому ищется не только среди соседей
+func (n *StorageNode) sectionEnd(start *StorageNode, name string) *StorageNode {
+	var end *StorageNode
+	after := false
+	n.walk(func(node *StorageNode) bool {
+		switch {
+		case end != nil:
+			return false
+		case node == start:
+			after = true
+			return false
+		case after && node.IsMacro("hfl"):
+			if v, ok := node.MacroParam(sectionEndParam); ok && v == name {
+				end = node
+				return false
+			}
+		}
+		return true
+	})
+	return end
+}
+
+// nodesBetween — узлы, лежащие в порядке документа строго между from и to, и anchor — предок from
+// (или сам from) на уровне общего с to предка, после которого вставляется новое содержимое
+func nodesBetween(from, to *StorageNode) (anchor *StorageNode, between []*StorageNode) {
+	fromPath := map[*StorageNode]bool{}
+	for node := from; node != nil; node = node.Parent {
+		fromPath[node] = true
+	}
+	bound := to
+	for bound.Parent != nil && !fromPath[bound.Parent] {
+		bound = bound.Parent
+	}
+	common := bound.Parent
+	anchor = from
+	for anchor.Parent != common {
+		anchor = anchor.Parent
+	}
+	for node := from; node != anchor; node = node.Parent {
+		between = append(between, node.followingSiblings()...)
+	}
+	siblings := common.Children
+	between = append(between, siblings[slices.Index(siblings, anchor)+1:slices.Index(siblings, bound)]...)
+	var tail []*StorageNode
+	for node := to; node != bound; node = node.Parent {
+		tail = append(slices.Clone(node.precedingSiblings()), tail...)
+	}
+	return anchor, append(between, tail...)
+}
+
+func (n *StorageNode) followingSiblings() []*StorageNode {
+	siblings := n.Parent.Children
+	return siblings[slices.Index(siblings, n)+1:]
+}
+
+func (n *StorageNode) precedingSiblings() []*StorageNode {
+	siblings := n.Parent.Children
+	return siblings[:slices.Index(siblings, n)]
+}
+
+// detach — убрать узел из документа
+func (n *StorageNode) detach() {
+	if n.Parent == nil {
+		return
+	}
+	n.Parent.Children = slices.DeleteFunc(n.Parent.Children, func(child *StorageNode) bool { return child == n })
+	n.Parent = nil
+}
+
+// insertAfter — вставить разобранный фрагмент сразу после узла
+func (n *StorageNode) insertAfter(xhtml string) error {
+	fragment, err := ParseStorage(xhtml)
+	if err != nil {
+		return err
+	}
+	parent := n.Parent
+	for _, child := range fragment.Children {
+		child.Parent = parent
+	}
+	idx := slices.Index(parent.Children, n)
+	parent.Children = slices.Insert(parent.Children, idx+1, fragment.Children...)
+	return nil
+}
+
+func renderNodes(nodes []*StorageNode) string {
+	var b strings.Builder
+	for _, node := range nodes {
+		node.render(&b)
+	}
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySections(t *testing.T) {
+	page := "<p>Введение от автора</p>" + RenderSection("changelog", "<p>v1</p>") + "<p>Заметки</p>" + RenderSection("tasks", "<p>CDI-1</p>")
+
+	t.Run("01. Раздел не изменился", func(t *testing.T) {
+		res, changed, err := applySections(page, []Section{{Name: "changelog", Content: "<p>v1</p>"}}, false)
+		require.NoError(t, err)
+		require.False(t, changed)
+		require.Equal(t, page, res)
+	})
+
+	t.Run("02. Заменяется только свой раздел", func(t *testing.T) {
+		res, changed, err := applySections(page, []Section{{Name: "changelog", Content: "<p>v2 &amp; v3</p>"}}, false)
+		require.NoError(t, err)
+		require.True(t, changed)
+		require.Contains(t, res, "<p>Введение от автора</p>")
+		require.Contains(t, res, "<p>Заметки</p>")
+		require.Contains(t, res, "<p>CDI-1</p>")
+		require.NotContains(t, res, "<p>v1</p>")
+		require.Contains(t, res, "<p>v2 &amp; v3</p>")
+
+		// Повторное применение ничего не меняет — хеш обновился вместе с разделом
+		again, changed, err := applySections(res, []Section{{Name: "changelog", Content: "<p>v2 &amp; v3</p>"}}, false)
+		require.NoError(t, err)
+		require.False(t, changed)
+		require.Equal(t, res, again)
+
+		doc, err := ParseStorage(res)
+		require.NoError(t, err)
+		require.Empty(t, extractHashcodeFromContent(res))
+		require.Len(t, doc.MacrosByParam("hfl", sectionParam, "changelog"), 1)
+	})
+
+	t.Run("03. Новый раздел добавляется в конец", func(t *testing.T) {
+		res, changed, err := applySections(page, []Section{{Name: "release", Content: "<p>1.0</p>"}}, false)
+		require.NoError(t, err)
+		require.True(t, changed)
+		require.Equal(t, page+RenderSection("release", "<p>1.0</p>"), res)
+	})
+
+	t.Run("04. Нет конца раздела", func(t *testing.T) {
+		broken := "<p>текст</p>" + fmt.Sprintf(SectionStart, "changelog", hashContent("<p>v1</p>")) + "<p>v1</p>"
+		_, _, err := applySections(broken, []Section{{Name: "changelog", Content: "<p>v2</p>"}}, false)
+		require.ErrorContains(t, err, "end of section")
+	})
+
+	t.Run("05. Границы раздела обёрнуты в абзацы", func(t *testing.T) {
+		start := fmt.Sprintf(SectionStart, "changelog", hashContent("<p>v1</p>"))
+		end := fmt.Sprintf(SectionEnd, "changelog")
+		wrapped := "<p>до</p><p>" + start + "</p><p>v1</p><p>" + end + "</p><p>после</p>"
+		res, changed, err := applySections(wrapped, []Section{{Name: "changelog", Content: "<p>v2</p>"}}, false)
+		require.NoError(t, err)
+		require.True(t, changed)
+		require.NotContains(t, res, "<p>v1</p>")
+		require.Contains(t, res, "<p>"+hashContent("<p>v2</p>")+"</p>")
+		require.Contains(t, res, "</ac:structured-macro></p>\n<p>v2</p>\n<p>"+strings.TrimPrefix(end, "\n"))
+		require.Contains(t, res, "<p>после</p>")
+
+		again, changed, err := applySections(res, []Section{{Name: "changelog", Content: "<p>v2</p>"}}, false)
+		require.NoError(t, err)
+		require.False(t, changed)
+		require.Equal(t, res, again)
+	})
+
+	t.Run("06. Ручная правка раздела", func(t *testing.T) {
+		edited := strings.Replace(page, "<p>v1</p>", "<p>v1, поправлено руками</p>", 1)
+		// Последнюю версию записала автоматизация — хешу верим
+		res, changed, err := applySections(edited, []Section{{Name: "changelog", Content: "<p>v1</p>"}}, true)
+		require.NoError(t, err)
+		require.False(t, changed)
+		require.Equal(t, edited, res)
+
+		res, changed, err = applySections(edited, []Section{{Name: "changelog", Content: "<p>v1</p>"}}, false)
+		require.NoError(t, err)
+		require.True(t, changed)
+		require.NotContains(t, res, "поправлено руками")
+		require.Contains(t, res, "<p>v1</p>")
+		require.Contains(t, res, "<p>Заметки</p>")
+	})
+}
+
+func TestUpdateSection(t *testing.T) {
+	var updated PageInfo
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/content/100", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&updated))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(PageInfo{
+			Id: "100", Title: "Релиз", Version: &PageVersion{Number: 3},
+			Body: &PageBody{Storage: PageStorage{Value: "<p>Ручной текст</p>", Representation: "storage"}},
+		}))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "user", "password")
+
+	require.NoError(t, c.UpdateSection(context.Background(), "100", "changelog", "<p>v1</p>"))
+	require.Equal(t, 4, updated.Version.Number)
+	require.Equal(t, "Релиз", updated.Title)
+	require.Equal(t, "<p>Ручной текст</p>"+RenderSection("changelog", "<p>v1</p>"), updated.Body.Storage.Value)
+}
@@ -0,0 +1,128 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// spaceUrl — адрес /rest/api/space рядом с baseUrl (/rest/api/content)
+func (c *confluence) spaceUrl() string {
+	return strings.TrimSuffix(strings.TrimRight(c.baseUrl, "/"), "/content") + "/space"
+}
+
+func (c *confluence) GetSpace(ctx context.Context, key string) (Space, error) {
+	if key == "" {
+		return Space{}, fmt.Errorf("key cannot be empty")
+	}
+	var space Space
+	err := requests.
+		URL(fmt.Sprintf("%s/%s", c.spaceUrl(), key)).
+		Method(http.MethodGet).
+		Param("expand", "description.plain,homepage").
+		BasicAuth(c.user, c.password).
+		ToJSON(&space).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return space, fmt.Errorf("GetSpace — get confluence space %s err: %w", key, err)
+	}
+	return space, nil
+}
+
+// ListSpaces — все пространства, подходящие под фильтры, постранично
+func (c *confluence) ListSpaces(ctx context.Context, opts ListSpacesOptions) ([]Space, error) {
+	builder := requests.URL(c.spaceUrl())
+	if opts.Type != "" {
+		builder.Param("type", opts.Type)
+	}
+	if opts.Status != "" {
+		builder.Param("status", opts.Status)
+	}
+	if len(opts.Labels) > 0 {
+		builder.Param("label", opts.Labels...)
+	}
+	spaces, err := collectAll(paginate[Space](ctx, c, builder, opts.Limit))
+	if err != nil {
+		return spaces, fmt.Errorf("ListSpaces — list confluence spaces err: %w", err)
+	}
+	return spaces, nil
+}
+
+// CreateSpace — новое пространство. Confluence сразу создаёт в нём домашнюю страницу
+func (c *confluence) CreateSpace(ctx context.Context, key, name, description string) (Space, error) {
+	if key == "" {
+		return Space{}, fmt.Errorf("key cannot be empty")
+	}
+	if name == "" {
+		return Space{}, fmt.Errorf("name cannot be empty")
+	}
+	req := Space{Key: key, Name: name}
+	if description != "" {
+		req.Description = &SpaceDescription{Plain: PageStorage{Value: description, Representation: "plain"}}
+	}
+	var space Space
+	err := requests.
+		URL(c.spaceUrl()).
+		Method(http.MethodPost).
+		ContentType("application/json").
+		BasicAuth(c.user, c.password).
+		BodyJSON(req).
+		ToJSON(&space).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return space, fmt.Errorf("CreateSpace — create confluence space %s err: %w", key, err)
+	}
+	return space, nil
+}
+
+func (c *confluence) GetSpaceHomepage(ctx context.Context, key string) (PageInfo, error) {
+	space, err := c.GetSpace(ctx, key)
+	if err != nil {
+		return PageInfo{}, fmt.Errorf("GetSpaceHomepage — %w", err)
+	}
+	if space.Homepage == nil {
+		return PageInfo{}, fmt.Errorf("GetSpaceHomepage — space %s has no homepage: %w", key, ErrNotFound)
+	}
+	return *space.Homepage, nil
+}
+
+// GetSpacePermissions — права пространства по операциям. Требуются права администратора пространства
+func (c *confluence) GetSpacePermissions(ctx context.Context, key string) ([]SpacePermission, error) {
+	if key == "" {
+		return nil, fmt.Errorf("key cannot be empty")
+	}
+	var space Space
+	err := requests.
+		URL(fmt.Sprintf("%s/%s", c.spaceUrl(), key)).
+		Method(http.MethodGet).
+		Param("expand", "permissions").
+		BasicAuth(c.user, c.password).
+		ToJSON(&space).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetSpacePermissions — get confluence space %s permissions err: %w", key, err)
+	}
+	return space.Permissions, nil
+}
+
+// BootstrapSpace — создать пространство и развернуть дерево страниц из шаблона под его домашней страницей
+func BootstrapSpace(ctx context.Context, api ApiConfluence, key, name, description string, template []DesiredPage) (Space, error) {
+	space, err := api.CreateSpace(ctx, key, name, description)
+	if err != nil {
+		return space, err
+	}
+	homepage, err := api.GetSpaceHomepage(ctx, key)
+	if err != nil {
+		return space, err
+	}
+	if _, err := SyncTree(ctx, api, homepage.Id, template, SyncTreeOptions{SpaceKey: key}); err != nil {
+		return space, fmt.Errorf("BootstrapSpace — space %s err: %w", key, err)
+	}
+	return space, nil
+}
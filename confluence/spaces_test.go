@@ -0,0 +1,103 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpaces(t *testing.T) {
+	var created Space
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /rest/api/space/{key}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("expand") == "permissions" {
+			_, _ = w.Write([]byte(`{"key": "ACME", "permissions": [
+				{"operation": {"operation": "read", "targetType": "space"}, "subjects": {"group": {"results": [{"type": "group", "name": "acme-users"}]}}},
+				{"operation": {"operation": "administer", "targetType": "space"}, "subjects": {"user": {"results": [{"username": "automation"}]}}, "anonymousAccess": false}
+			]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 5, "key": "ACME", "name": "Acme", "type": "global", "status": "current",
+			"description": {"plain": {"value": "Документация Acme", "representation": "plain"}},
+			"homepage": {"id": "500", "title": "Acme Home"}}`))
+	})
+	mux.HandleFunc("GET /rest/api/space", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "global", r.URL.Query().Get("type"))
+		require.Equal(t, []string{"customer", "partner"}, r.URL.Query()["label"])
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		keys := []string{"ACME", "GLOBEX", "INITECH"}
+		resp := pagedResponse[Space]{Start: start, Limit: 2}
+		for _, key := range keys[min(start, len(keys)):min(start+2, len(keys))] {
+			resp.Results = append(resp.Results, Space{Key: key})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	})
+	mux.HandleFunc("POST /rest/api/space", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 6, "key": "NEW", "name": "New", "homepage": {"id": "600"}}`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	c := NewConfluence(srv.URL+"/rest/api/content", "user", "password")
+	ctx := context.Background()
+
+	t.Run("01. Пространство и домашняя страница", func(t *testing.T) {
+		space, err := c.GetSpace(ctx, "ACME")
+		require.NoError(t, err)
+		require.Equal(t, "Документация Acme", space.Description.Plain.Value)
+		homepage, err := c.GetSpaceHomepage(ctx, "ACME")
+		require.NoError(t, err)
+		require.Equal(t, "500", homepage.Id)
+	})
+
+	t.Run("02. Список пространств постранично", func(t *testing.T) {
+		spaces, err := c.ListSpaces(ctx, ListSpacesOptions{Type: "global", Labels: []string{"customer", "partner"}, Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, spaces, 3)
+		require.Equal(t, "INITECH", spaces[2].Key)
+	})
+
+	t.Run("03. Создание пространства", func(t *testing.T) {
+		space, err := c.CreateSpace(ctx, "NEW", "New", "Новый заказчик")
+		require.NoError(t, err)
+		require.Equal(t, "600", space.Homepage.Id)
+		require.Equal(t, Space{Key: "NEW", Name: "New", Description: &SpaceDescription{
+			Plain: PageStorage{Value: "Новый заказчик", Representation: "plain"},
+		}}, created)
+	})
+
+	t.Run("04. Права пространства", func(t *testing.T) {
+		permissions, err := c.GetSpacePermissions(ctx, "ACME")
+		require.NoError(t, err)
+		require.Len(t, permissions, 2)
+		require.Equal(t, "read", permissions[0].Operation.Operation)
+		require.Equal(t, RestrictionTargets{Groups: []string{"acme-users"}}, permissions[0].Subjects.Targets())
+		require.Equal(t, RestrictionTargets{Users: []string{"automation"}}, permissions[1].Subjects.Targets())
+	})
+}
+
+func (f *treeConfluence) CreateSpace(_ context.Context, key, _, _ string) (Space, error) {
+	f.pages = append(f.pages, &fakePage{id: "home-" + key, title: key + " Home"})
+	f.calls = append(f.calls, "space "+key)
+	return Space{Key: key}, nil
+}
+
+func (f *treeConfluence) GetSpaceHomepage(_ context.Context, key string) (PageInfo, error) {
+	return PageInfo{Id: "home-" + key}, nil
+}
+
+func TestBootstrapSpace(t *testing.T) {
+	api := &treeConfluence{}
+	template := []DesiredPage{{Title: "Интеграция", Content: "<p>шаги</p>", Children: []DesiredPage{{Title: "API", Content: "<p>api</p>"}}}}
+	_, err := BootstrapSpace(context.Background(), api, "ACME", "Acme", "", template)
+	require.NoError(t, err)
+	require.Equal(t, []string{"space ACME", "create Интеграция in home-ACME", "create API in 1001"}, api.calls)
+}
@@ -0,0 +1,478 @@
+package confluence
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+)
+
+type StorageNodeType int
+
+const (
+	DocumentNode StorageNodeType = iota
+	ElementNode
+	TextNode
+	CDataNode
+	CommentNode
+)
+
+// StorageAttr — атрибут тега
+type StorageAttr struct {
+	Name  string
+	Value string
+}
+
+// StorageNode — узел разобранного документа в формате хранения.
+// Узел помнит исходный текст, поэтому неизменённые части документа сериализуются байт в байт
+type StorageNode struct {
+	Type     StorageNodeType
+	Name     string // имя тега, например p или ac:structured-macro
+	Attrs    []StorageAttr
+	Data     string // текст как в документе (с сущностями) или содержимое CDATA и комментария
+	Parent   *StorageNode
+	Children []*StorageNode
+
+	rawStart    string // исходный открывающий тег
+	rawEnd      string // исходный закрывающий тег
+	selfClosing bool
+	unclosed    bool // в документе нет закрывающего тега, при сериализации он не добавляется
+	changed     bool // атрибуты менялись, открывающий тег собирается заново
+}
+
+// ParseStorage — разобрать документ в формате хранения в дерево узлов.
+// Незакрытые теги закрываются в конце документа, лишние закрывающие теги сохраняются как текст
+func ParseStorage(content string) (*StorageNode, error) {
+	root := &StorageNode{Type: DocumentNode}
+	current := root
+	for pos := 0; pos < len(content); {
+		if content[pos] != '<' {
+			end := strings.IndexByte(content[pos:], '<')
+			if end < 0 {
+				end = len(content) - pos
+			}
+			current.appendChild(&StorageNode{Type: TextNode, Data: content[pos : pos+end]})
+			pos += end
+			continue
+		}
+		rest := content[pos:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest, "-->")
+			if end < 0 {
+				return nil, fmt.Errorf("ParseStorage — unterminated comment at %d", pos)
+			}
+			current.appendChild(&StorageNode{Type: CommentNode, Data: rest[4:end]})
+			pos += end + 3
+		case strings.HasPrefix(rest, "<![CDATA["):
+			end := strings.Index(rest, "]]>")
+			if end < 0 {
+				return nil, fmt.Errorf("ParseStorage — unterminated CDATA at %d", pos)
+			}
+			current.appendChild(&StorageNode{Type: CDataNode, Data: rest[9:end]})
+			pos += end + 3
+		case strings.HasPrefix(rest, "</"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return nil, fmt.Errorf("ParseStorage — unterminated tag at %d", pos)
+			}
+			raw := rest[:end+1]
+			name := strings.TrimSpace(rest[2:end])
+			if open := current.findOpen(name); open != nil {
+				for node := current; node != open; node = node.Parent {
+					node.unclosed = true
+				}
+				open.rawEnd = raw
+				current = open.Parent
+			} else {
+				current.appendChild(&StorageNode{Type: TextNode, Data: raw})
+			}
+			pos += end + 1
+		case len(rest) < 2 || !isNameStart(rest[1]):
+			// «<» без имени тега — просто текст
+			current.appendChild(&StorageNode{Type: TextNode, Data: "<"})
+			pos++
+		default:
+			end := tagEnd(rest)
+			if end < 0 {
+				return nil, fmt.Errorf("ParseStorage — unterminated tag at %d", pos)
+			}
+			raw := rest[:end+1]
+			node := parseStartTag(raw)
+			current.appendChild(node)
+			if !node.selfClosing {
+				current = node
+			}
+			pos += end + 1
+		}
+	}
+	for node := current; node != root; node = node.Parent {
+		node.unclosed = true
+	}
+	return root, nil
+}
+
+// tagEnd — позиция «>», закрывающей тег, с учётом кавычек в значениях атрибутов
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i
+		}
+	}
+	return -1
+}
+
+func parseStartTag(raw string) *StorageNode {
+	body := strings.TrimSuffix(raw[1:], ">")
+	node := &StorageNode{Type: ElementNode, rawStart: raw}
+	if strings.HasSuffix(body, "/") {
+		node.selfClosing = true
+		body = body[:len(body)-1]
+	}
+	nameEnd := strings.IndexAny(body, " \t\r\n")
+	if nameEnd < 0 {
+		nameEnd = len(body)
+	}
+	node.Name = body[:nameEnd]
+	attrs := body[nameEnd:]
+	for {
+		attrs = strings.TrimLeft(attrs, " \t\r\n")
+		if attrs == "" {
+			break
+		}
+		nameEnd := strings.IndexAny(attrs, "= \t\r\n")
+		if nameEnd < 0 {
+			node.Attrs = append(node.Attrs, StorageAttr{Name: attrs})
+			break
+		}
+		attr := StorageAttr{Name: attrs[:nameEnd]}
+		attrs = strings.TrimLeft(attrs[nameEnd:], " \t\r\n")
+		if strings.HasPrefix(attrs, "=") {
+			attrs = strings.TrimLeft(attrs[1:], " \t\r\n")
+			if attrs != "" && (attrs[0] == '"' || attrs[0] == '\'') {
+				valueEnd := strings.IndexByte(attrs[1:], attrs[0])
+				if valueEnd < 0 {
+					valueEnd = len(attrs) - 1
+				}
+				attr.Value = html.UnescapeString(attrs[1 : valueEnd+1])
+				attrs = attrs[min(valueEnd+2, len(attrs)):]
+			} else {
+				valueEnd := strings.IndexAny(attrs, " \t\r\n")
+				if valueEnd < 0 {
+					valueEnd = len(attrs)
+				}
+				attr.Value = html.UnescapeString(attrs[:valueEnd])
+				attrs = attrs[valueEnd:]
+			}
+		}
+		node.Attrs = append(node.Attrs, attr)
+	}
+	return node
+}
+
+func isNameStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func (n *StorageNode) appendChild(child *StorageNode) {
+	child.Parent = n
+	n.Children = append(n.Children, child)
+}
+
+// findOpen — ближайший незакрытый предок (или сам узел) с именем name
+func (n *StorageNode) findOpen(name string) *StorageNode {
+	for node := n; node != nil && node.Type == ElementNode; node = node.Parent {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// String — узел и его потомки в формате хранения
+func (n *StorageNode) String() string {
+	var b strings.Builder
+	n.render(&b)
+	return b.String()
+}
+
+// InnerString — содержимое узла без его собственных тегов
+func (n *StorageNode) InnerString() string {
+	var b strings.Builder
+	for _, child := range n.Children {
+		child.render(&b)
+	}
+	return b.String()
+}
+
+func (n *StorageNode) render(b *strings.Builder) {
+	switch n.Type {
+	case TextNode:
+		b.WriteString(n.Data)
+		return
+	case CDataNode:
+		b.WriteString("<![CDATA[" + n.Data + "]]>")
+		return
+	case CommentNode:
+		b.WriteString("<!--" + n.Data + "-->")
+		return
+	case DocumentNode:
+		for _, child := range n.Children {
+			child.render(b)
+		}
+		return
+	}
+	b.WriteString(n.startTag())
+	if n.selfClosing && len(n.Children) == 0 {
+		return
+	}
+	for _, child := range n.Children {
+		child.render(b)
+	}
+	switch {
+	case n.rawEnd != "":
+		b.WriteString(n.rawEnd)
+	case !n.unclosed:
+		b.WriteString("</" + n.Name + ">")
+	}
+}
+
+func (n *StorageNode) startTag() string {
+	if n.rawStart != "" && !n.changed && !(n.selfClosing && len(n.Children) > 0) {
+		return n.rawStart
+	}
+	var b strings.Builder
+	b.WriteString("<" + n.Name)
+	for _, attr := range n.Attrs {
+		fmt.Fprintf(&b, ` %s="%s"`, attr.Name, html.EscapeString(attr.Value))
+	}
+	if n.selfClosing && len(n.Children) == 0 {
+		b.WriteString(" />")
+	} else {
+		b.WriteString(">")
+	}
+	return b.String()
+}
+
+// Attr — значение атрибута
+func (n *StorageNode) Attr(name string) (string, bool) {
+	for _, attr := range n.Attrs {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// SetAttr — задать значение атрибута
+func (n *StorageNode) SetAttr(name, value string) {
+	n.changed = true
+	for i := range n.Attrs {
+		if n.Attrs[i].Name == name {
+			n.Attrs[i].Value = value
+			return
+		}
+	}
+	n.Attrs = append(n.Attrs, StorageAttr{Name: name, Value: value})
+}
+
+// Text — текстовое содержимое узла без тегов, сущности раскрываются
+func (n *StorageNode) Text() string {
+	switch n.Type {
+	case TextNode:
+		return html.UnescapeString(n.Data)
+	case CDataNode:
+		return n.Data
+	case CommentNode:
+		return ""
+	}
+	var b strings.Builder
+	for _, child := range n.Children {
+		b.WriteString(child.Text())
+	}
+	return b.String()
+}
+
+// Find — все узлы поддерева (включая сам узел), подходящие под условие, в порядке документа
+func (n *StorageNode) Find(match func(node *StorageNode) bool) []*StorageNode {
+	var res []*StorageNode
+	n.walk(func(node *StorageNode) bool {
+		if match(node) {
+			res = append(res, node)
+		}
+		return true
+	})
+	return res
+}
+
+// walk — обход в глубину; fn возвращает false, чтобы не заходить в потомков узла
+func (n *StorageNode) walk(fn func(node *StorageNode) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range n.Children {
+		child.walk(fn)
+	}
+}
+
+// SetInner — заменить содержимое узла разобранным фрагментом xhtml
+func (n *StorageNode) SetInner(xhtml string) error {
+	fragment, err := ParseStorage(xhtml)
+	if err != nil {
+		return err
+	}
+	n.Children = nil
+	for _, child := range fragment.Children {
+		n.appendChild(child)
+	}
+	return nil
+}
+
+// ReplaceWith — заменить узел разобранным фрагментом xhtml. Корень документа и узлы,
+// уже убранные из документа, заменить нельзя — для корня используйте SetInner
+func (n *StorageNode) ReplaceWith(xhtml string) error {
+	if n.Parent == nil {
+		return fmt.Errorf("ReplaceWith — node %s is not attached to a document", n.Name)
+	}
+	parent := n.Parent
+	idx := slices.Index(parent.Children, n)
+	if idx < 0 {
+		return fmt.Errorf("ReplaceWith — node %s is not a child of its parent", n.Name)
+	}
+	fragment, err := ParseStorage(xhtml)
+	if err != nil {
+		return err
+	}
+	for _, child := range fragment.Children {
+		child.Parent = parent
+	}
+	parent.Children = slices.Concat(parent.Children[:idx], fragment.Children, parent.Children[idx+1:])
+	n.Parent = nil
+	return nil
+}
+
+const (
+	macroTag          = "ac:structured-macro"
+	macroParamTag     = "ac:parameter"
+	macroRichBodyTag  = "ac:rich-text-body"
+	macroPlainBodyTag = "ac:plain-text-body"
+	macroNameAttr     = "ac:name"
+)
+
+// IsMacro — является ли узел макросом, name пустое — любым
+func (n *StorageNode) IsMacro(name string) bool {
+	if n.Type != ElementNode || n.Name != macroTag {
+		return false
+	}
+	macroName, _ := n.Attr(macroNameAttr)
+	return name == "" || macroName == name
+}
+
+// MacroName — имя макроса
+func (n *StorageNode) MacroName() string {
+	name, _ := n.Attr(macroNameAttr)
+	return name
+}
+
+// MacroParam — значение параметра макроса
+func (n *StorageNode) MacroParam(name string) (string, bool) {
+	for _, child := range n.Children {
+		if child.Type == ElementNode && child.Name == macroParamTag {
+			if paramName, _ := child.Attr(macroNameAttr); paramName == name {
+				return child.Text(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// Macros — макросы поддерева с именем name (пустое — все) в порядке документа, включая вложенные
+func (n *StorageNode) Macros(name string) []*StorageNode {
+	return n.Find(func(node *StorageNode) bool { return node.IsMacro(name) })
+}
+
+// MacrosByParam — макросы с именем name (пустое — любые), у которых параметр param равен value
+func (n *StorageNode) MacrosByParam(name, param, value string) []*StorageNode {
+	return n.Find(func(node *StorageNode) bool {
+		if !node.IsMacro(name) {
+			return false
+		}
+		v, ok := node.MacroParam(param)
+		return ok && v == value
+	})
+}
+
+// MacroBody — тело макроса: ac:rich-text-body или ac:plain-text-body, nil если тела нет
+func (n *StorageNode) MacroBody() *StorageNode {
+	for _, child := range n.Children {
+		if child.Type == ElementNode && (child.Name == macroRichBodyTag || child.Name == macroPlainBodyTag) {
+			return child
+		}
+	}
+	return nil
+}
+
+// SetMacroBody — заменить rich text тело макроса, при отсутствии тело создаётся
+func (n *StorageNode) SetMacroBody(xhtml string) error {
+	if !n.IsMacro("") {
+		return fmt.Errorf("SetMacroBody — node %s is not a macro", n.Name)
+	}
+	body := n.MacroBody()
+	if body == nil {
+		body = &StorageNode{Type: ElementNode, Name: macroRichBodyTag}
+		n.appendChild(body)
+	}
+	return body.SetInner(xhtml)
+}
+
+// Tables — таблицы поддерева в порядке документа
+func (n *StorageNode) Tables() []*StorageNode {
+	return n.Find(func(node *StorageNode) bool { return node.Type == ElementNode && node.Name == "table" })
+}
+
+// TableCells — ячейки (th и td) таблицы по строкам. Строки вложенных таблиц не учитываются
+func (n *StorageNode) TableCells() [][]*StorageNode {
+	var rows [][]*StorageNode
+	n.walk(func(node *StorageNode) bool {
+		if node.Type != ElementNode {
+			return false
+		}
+		if node != n && node.Name == "table" {
+			return false
+		}
+		if node.Name != "tr" {
+			return true
+		}
+		var cells []*StorageNode
+		for _, child := range node.Children {
+			if child.Type == ElementNode && (child.Name == "td" || child.Name == "th") {
+				cells = append(cells, child)
+			}
+		}
+		rows = append(rows, cells)
+		return false
+	})
+	return rows
+}
+
+// TableRows — текст ячеек таблицы по строкам, с обрезанными пробелами по краям
+func (n *StorageNode) TableRows() [][]string {
+	cells := n.TableCells()
+	rows := make([][]string, 0, len(cells))
+	for _, row := range cells {
+		texts := make([]string, 0, len(row))
+		for _, cell := range row {
+			texts = append(texts, strings.TrimSpace(cell.Text()))
+		}
+		rows = append(rows, texts)
+	}
+	return rows
+}
@@ -0,0 +1,94 @@
+package confluence
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testStorageDoc = `<p>Ручной текст &laquo;до&raquo;<br/>строка</p>
+<ac:structured-macro ac:name="expand" ac:schema-version="1"><ac:parameter ac:name="title">Детали</ac:parameter><ac:rich-text-body><p>старое</p></ac:rich-text-body></ac:structured-macro>
+<ac:structured-macro ac:name="code"><ac:plain-text-body><![CDATA[a < b && c]]></ac:plain-text-body></ac:structured-macro>
+<ac:structured-macro ac:name="toc" />
+<!-- комментарий -->
+<table class='wrapped'><tbody>
+<tr><th>Поле</th><th>Описание</th></tr>
+<tr><td> id </td><td><p>Идентификатор &amp; ключ</p><table><tbody><tr><td>вложенная</td></tr></tbody></table></td></tr>
+</tbody></table>
+<hr />`
+
+func TestParseStorage(t *testing.T) {
+	t.Run("01. Сериализация без изменений", func(t *testing.T) {
+		files := []string{"test_data/content_with_hashcode.txt", "test_data/content_with_hashcode_start.txt"}
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+			doc, err := ParseStorage(string(content))
+			require.NoError(t, err)
+			require.Equal(t, string(content), doc.String())
+		}
+		for _, content := range []string{testStorageDoc, "<p>незакрытый <b>тег", "текст</p> a < b"} {
+			doc, err := ParseStorage(content)
+			require.NoError(t, err)
+			require.Equal(t, content, doc.String())
+		}
+	})
+
+	t.Run("02. Поиск макросов", func(t *testing.T) {
+		doc, err := ParseStorage(testStorageDoc)
+		require.NoError(t, err)
+		require.Len(t, doc.Macros(""), 3)
+		code := doc.Macros("code")
+		require.Len(t, code, 1)
+		require.Equal(t, "a < b && c", code[0].MacroBody().Text())
+
+		expand := doc.MacrosByParam("expand", "title", "Детали")
+		require.Len(t, expand, 1)
+		require.Empty(t, doc.MacrosByParam("", "title", "Другое"))
+	})
+
+	t.Run("03. Замена тела макроса", func(t *testing.T) {
+		doc, err := ParseStorage(testStorageDoc)
+		require.NoError(t, err)
+		require.NoError(t, doc.MacrosByParam("expand", "title", "Детали")[0].SetMacroBody("<p>новое</p>"))
+		require.NoError(t, doc.Macros("toc")[0].SetMacroBody("<p>тело</p>"))
+
+		want := `<ac:structured-macro ac:name="expand" ac:schema-version="1"><ac:parameter ac:name="title">Детали</ac:parameter>` +
+			`<ac:rich-text-body><p>новое</p></ac:rich-text-body></ac:structured-macro>`
+		require.Contains(t, doc.String(), want)
+		require.Contains(t, doc.String(), `<ac:structured-macro ac:name="toc"><ac:rich-text-body><p>тело</p></ac:rich-text-body></ac:structured-macro>`)
+		require.Contains(t, doc.String(), "<p>Ручной текст &laquo;до&raquo;<br/>строка</p>")
+	})
+
+	t.Run("04. Замена узла", func(t *testing.T) {
+		doc, err := ParseStorage(`<p>первый</p><hr /><p>третий</p>`)
+		require.NoError(t, err)
+		hr := doc.Children[1]
+		require.NoError(t, hr.ReplaceWith("<p>второй</p>"))
+		require.Equal(t, `<p>первый</p><p>второй</p><p>третий</p>`, doc.String())
+
+		// Повторная замена уже убранного узла не трогает документ
+		require.Error(t, hr.ReplaceWith("<p>лишний</p>"))
+		third := doc.Children[2]
+		require.NoError(t, doc.SetInner("<p>новый</p>"))
+		require.Error(t, third.ReplaceWith("<p>лишний</p>"))
+		require.Equal(t, `<p>новый</p>`, doc.String())
+
+		require.Error(t, doc.ReplaceWith("<p>корень</p>"))
+	})
+
+	t.Run("05. Чтение таблиц", func(t *testing.T) {
+		doc, err := ParseStorage(testStorageDoc)
+		require.NoError(t, err)
+		tables := doc.Tables()
+		require.Len(t, tables, 2)
+		class, _ := tables[0].Attr("class")
+		require.Equal(t, "wrapped", class)
+		require.Equal(t, [][]string{
+			{"Поле", "Описание"},
+			{"id", "Идентификатор & ключвложенная"},
+		}, tables[0].TableRows())
+		require.Equal(t, [][]string{{"вложенная"}}, tables[1].TableRows())
+	})
+}
@@ -0,0 +1,416 @@
+package confluence
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DesiredPage — страница, которая должна быть в дереве, вместе с дочерними
+type DesiredPage struct {
+	Title       string
+	Content     string   // содержимое в формате хранения, хеш добавляется автоматически
+	Labels      []string // добавляются к меткам страницы, чужие метки не удаляются
+	Attachments []DesiredAttachment
+	Children    []DesiredPage
+}
+
+type DesiredAttachment struct {
+	Title string
+	Data  []byte
+}
+
+// OrphanPolicy — что делать со сгенерированными страницами дерева, которых нет в желаемом состоянии
+type OrphanPolicy int
+
+const (
+	OrphanKeep    OrphanPolicy = iota // оставить как есть
+	OrphanArchive                     // перенести под SyncTreeOptions.ArchivePageId
+	OrphanDelete                      // удалить в корзину
+)
+
+type SyncTreeOptions struct {
+	DryRun        bool // только построить план
+	Orphans       OrphanPolicy
+	ArchivePageId string
+	SpaceKey      string // пространство для новых страниц, по умолчанию — пространство корня
+	AdoptLabel    string // страницу вне дерева с такой меткой можно перенести в дерево, даже если она без хеша
+}
+
+type SyncActionType string
+
+const (
+	SyncCreate     SyncActionType = "create"
+	SyncUpdate     SyncActionType = "update"
+	SyncMove       SyncActionType = "move"
+	SyncAddLabel   SyncActionType = "label"
+	SyncAttachment SyncActionType = "attachment"
+	SyncArchive    SyncActionType = "archive"
+	SyncDelete     SyncActionType = "delete"
+)
+
+// SyncAction — одно изменение плана синхронизации
+type SyncAction struct {
+	Type        SyncActionType
+	Title       string // страница
+	PageId      string // пусто у страниц, которые ещё будут созданы
+	ParentTitle string // родитель для create и move
+	Detail      string // метка или название вложения
+	page        *DesiredPage
+	descendants []string // сгенерированные страницы под удаляемой, удаляются вместе с ней, начиная с нижних
+}
+
+func (a SyncAction) String() string {
+	switch a.Type {
+	case SyncCreate:
+		return fmt.Sprintf("+ create «%s» under «%s»", a.Title, a.ParentTitle)
+	case SyncMove:
+		return fmt.Sprintf("~ move «%s» under «%s»", a.Title, a.ParentTitle)
+	case SyncArchive:
+		return fmt.Sprintf("- archive «%s»", a.Title)
+	case SyncDelete:
+		return fmt.Sprintf("- delete «%s»", a.Title)
+	case SyncAddLabel, SyncAttachment:
+		return fmt.Sprintf("~ %s «%s»: %s", a.Type, a.Title, a.Detail)
+	}
+	return fmt.Sprintf("~ %s «%s»", a.Type, a.Title)
+}
+
+// SyncPlan — изменения, нужные для приведения дерева к желаемому состоянию, в порядке применения
+type SyncPlan struct {
+	Actions []SyncAction
+	ids     map[string]string // идентификаторы уже существующих страниц по заголовкам
+}
+
+// String — план в виде списка изменений, по строке на действие
+func (p SyncPlan) String() string {
+	lines := make([]string, 0, len(p.Actions))
+	for _, action := range p.Actions {
+		lines = append(lines, action.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// existingPage — страница дерева и её текущий родитель
+type existingPage struct {
+	PageInfo
+	parentId string
+}
+
+// SyncTree — приводит дерево страниц под rootPageId к желаемому состоянию: создаёт недостающие страницы,
+// обновляет изменившиеся (по хешу содержимого), переносит страницы к нужному родителю, добавляет метки
+// и вложения. Метки только добавляются: SyncTree не удаляет метки, даже если их нет в желаемом состоянии.
+// Лишними считаются только сгенерированные страницы (с хешем), ручные страницы не трогаются.
+// Страница с нужным заголовком в другом месте пространства переносится в дерево, только если она сгенерирована
+// или помечена opts.AdoptLabel, иначе возвращается ошибка. Возвращает план; при opts.DryRun ничего не меняет
+func SyncTree(ctx context.Context, api ApiConfluence, rootPageId string, desired []DesiredPage, opts SyncTreeOptions) (SyncPlan, error) {
+	if rootPageId == "" {
+		return SyncPlan{}, fmt.Errorf("rootPageId cannot be empty")
+	}
+	if opts.Orphans == OrphanArchive && opts.ArchivePageId == "" {
+		return SyncPlan{}, fmt.Errorf("archivePageId cannot be empty with OrphanArchive")
+	}
+	root, err := api.GetPage(ctx, rootPageId, GetPageOptions{Expand: []string{ExpandSpace}})
+	if err != nil {
+		return SyncPlan{}, fmt.Errorf("SyncTree — get root pageId %s err: %w", rootPageId, err)
+	}
+	if opts.SpaceKey == "" && root.Space != nil {
+		opts.SpaceKey = root.Space.Key
+	}
+	plan, err := planTree(ctx, api, root, desired, opts)
+	if err != nil || opts.DryRun {
+		return plan, err
+	}
+	return plan, applyPlan(ctx, api, plan, opts)
+}
+
+func planTree(ctx context.Context, api ApiConfluence, root PageInfo, desired []DesiredPage, opts SyncTreeOptions) (SyncPlan, error) {
+	existing, err := collectTree(ctx, api, root.Id)
+	if err != nil {
+		return SyncPlan{}, err
+	}
+	plan := SyncPlan{ids: map[string]string{root.Title: root.Id}}
+	wanted := map[string]bool{root.Title: true}
+	var walk func(pages []DesiredPage, parent existingPage) error
+	walk = func(pages []DesiredPage, parent existingPage) error {
+		for i := range pages {
+			page := &pages[i]
+			if page.Title == "" {
+				return fmt.Errorf("SyncTree — desired page title cannot be empty")
+			}
+			if wanted[page.Title] {
+				return fmt.Errorf("SyncTree — duplicate desired page %q", page.Title)
+			}
+			wanted[page.Title] = true
+
+			current, found := existing[page.Title]
+			if !found {
+				// Заголовки уникальны в пространстве: страница может быть вне дерева
+				byName, err := api.GetPagesByName(ctx, page.Title, opts.SpaceKey)
+				if err != nil {
+					return err
+				}
+				if len(byName) > 0 {
+					ok, err := adoptable(ctx, api, byName[0].Id, opts)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						return fmt.Errorf("SyncTree — page %q already exists outside the tree (pageId %s) and is not generated", page.Title, byName[0].Id)
+					}
+					current, found = existingPage{PageInfo: byName[0]}, true
+				}
+			}
+			if !found {
+				plan.Actions = append(plan.Actions, SyncAction{Type: SyncCreate, Title: page.Title, ParentTitle: parent.Title, page: page})
+				if err := walk(page.Children, existingPage{PageInfo: PageInfo{Title: page.Title}}); err != nil {
+					return err
+				}
+				continue
+			}
+			plan.ids[page.Title] = current.Id
+			actions, err := planPage(ctx, api, current, parent, page)
+			if err != nil {
+				return err
+			}
+			plan.Actions = append(plan.Actions, actions...)
+			if err := walk(page.Children, current); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(desired, existingPage{PageInfo: root}); err != nil {
+		return SyncPlan{}, err
+	}
+
+	if opts.Orphans == OrphanKeep {
+		return plan, nil
+	}
+	var orphans []existingPage
+	for title, page := range existing {
+		if !wanted[title] {
+			orphans = append(orphans, page)
+		}
+	}
+	slices.SortFunc(orphans, func(a, b existingPage) int { return strings.Compare(a.Title, b.Title) })
+	generated := map[string]bool{}
+	childOrphans := map[string][]string{}
+	var candidates []existingPage
+	for _, orphan := range orphans {
+		content, err := api.GetContentById(ctx, orphan.Id)
+		if err != nil {
+			return SyncPlan{}, err
+		}
+		if extractHashcodeFromContent(content) == "" {
+			continue
+		}
+		generated[orphan.Id] = true
+		childOrphans[orphan.parentId] = append(childOrphans[orphan.parentId], orphan.Id)
+		candidates = append(candidates, orphan)
+	}
+	for _, orphan := range candidates {
+		// Страница под лишней сгенерированной страницей переносится или удаляется вместе с ней
+		if generated[orphan.parentId] {
+			continue
+		}
+		action := SyncAction{Type: SyncDelete, Title: orphan.Title, PageId: orphan.Id}
+		if opts.Orphans == OrphanArchive {
+			if orphan.parentId == opts.ArchivePageId {
+				continue
+			}
+			action.Type = SyncArchive
+		} else {
+			// Confluence при удалении переносит дочерние страницы к родителю, поэтому они удаляются отдельно
+			action.descendants = orphanDescendants(childOrphans, orphan.Id)
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+	return plan, nil
+}
+
+// orphanDescendants — лишние сгенерированные страницы под id, сначала самые нижние
+func orphanDescendants(childOrphans map[string][]string, id string) []string {
+	var res []string
+	for _, child := range childOrphans[id] {
+		res = append(res, orphanDescendants(childOrphans, child)...)
+		res = append(res, child)
+	}
+	return res
+}
+
+// adoptable — можно ли перенести в дерево страницу из другого места пространства: только сгенерированную
+// (с хешем) или помеченную opts.AdoptLabel, чтобы не забрать ручную страницу с тем же заголовком
+func adoptable(ctx context.Context, api ApiConfluence, id string, opts SyncTreeOptions) (bool, error) {
+	content, err := api.GetContentById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if extractHashcodeFromContent(content) != "" {
+		return true, nil
+	}
+	if opts.AdoptLabel == "" {
+		return false, nil
+	}
+	labels, err := api.GetLabelsById(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(normalizeLabels(labels), normalizeLabel(opts.AdoptLabel)), nil
+}
+
+// planPage — изменения существующей страницы: содержимое, родитель, метки и вложения
+func planPage(ctx context.Context, api ApiConfluence, current, parent existingPage, page *DesiredPage) ([]SyncAction, error) {
+	var actions []SyncAction
+	newAction := func(actionType SyncActionType) SyncAction {
+		return SyncAction{Type: actionType, Title: page.Title, PageId: current.Id, ParentTitle: parent.Title, page: page}
+	}
+	content, err := api.GetContentById(ctx, current.Id)
+	if err != nil {
+		return nil, err
+	}
+	if extractHashcodeFromContent(content) != hashContent(page.Content) {
+		actions = append(actions, newAction(SyncUpdate))
+	}
+	if parent.Id == "" || current.parentId != parent.Id {
+		actions = append(actions, newAction(SyncMove))
+	}
+
+	labels, err := api.GetLabelsById(ctx, current.Id)
+	if err != nil {
+		return nil, err
+	}
+	labels = normalizeLabels(labels)
+	for _, label := range normalizeLabels(page.Labels) {
+		if !slices.Contains(labels, label) {
+			action := newAction(SyncAddLabel)
+			action.Detail = label
+			actions = append(actions, action)
+		}
+	}
+
+	if len(page.Attachments) == 0 {
+		return actions, nil
+	}
+	// Вложения сравниваются так же, как при применении плана: по размеру и хешу, без скачивания в память
+	res, err := SyncAttachments(ctx, api, desiredAttachments(page.Attachments), current.Id, SyncAttachmentsOptions{DryRun: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, title := range res.Uploaded {
+		action := newAction(SyncAttachment)
+		action.Detail = title
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// desiredAttachments — вложения желаемого состояния как источник SyncAttachments. Хеш считается по данным
+// в памяти и сохраняется в комментарии версии, поэтому следующий план обходится без скачивания вложений
+type desiredAttachments []DesiredAttachment
+
+func (d desiredAttachments) List(_ context.Context) ([]SourceAttachment, error) {
+	res := make([]SourceAttachment, 0, len(d))
+	for _, attachment := range d {
+		hash := sha256.Sum256(attachment.Data)
+		res = append(res, SourceAttachment{Title: attachment.Title, Size: int64(len(attachment.Data)), Hash: hex.EncodeToString(hash[:])})
+	}
+	return res, nil
+}
+
+func (d desiredAttachments) Open(_ context.Context, source SourceAttachment) (io.ReadCloser, error) {
+	for _, attachment := range d {
+		if attachment.Title == source.Title {
+			return io.NopCloser(bytes.NewReader(attachment.Data)), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// collectTree — все страницы дерева под rootId по заголовкам, вместе с их родителями
+func collectTree(ctx context.Context, api ApiConfluence, rootId string) (map[string]existingPage, error) {
+	pages := map[string]existingPage{}
+	queue := []string{rootId}
+	for len(queue) > 0 {
+		parentId := queue[0]
+		queue = queue[1:]
+		children, err := api.GetChildrenById(ctx, parentId, 500)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			pages[child.Title] = existingPage{PageInfo: child, parentId: parentId}
+			queue = append(queue, child.Id)
+		}
+	}
+	return pages, nil
+}
+
+// applyPlan — применить план по порядку. Родители создаются раньше детей, поэтому их идентификаторы уже известны
+func applyPlan(ctx context.Context, api ApiConfluence, plan SyncPlan, opts SyncTreeOptions) error {
+	ids := maps.Clone(plan.ids)
+	for _, action := range plan.Actions {
+		if action.PageId != "" {
+			ids[action.Title] = action.PageId
+		}
+	}
+	for _, action := range plan.Actions {
+		id := ids[action.Title]
+		var err error
+		switch action.Type {
+		case SyncCreate:
+			id, err = api.CreatePageWithHash(ctx, action.Title, opts.SpaceKey, action.page.Content, ids[action.ParentTitle])
+			if err == nil {
+				ids[action.Title] = id
+				err = applyNewPageExtras(ctx, api, id, action.page)
+			}
+		case SyncUpdate:
+			err = api.UpdatePageByIdWithCheck(ctx, id, action.page.Content, false)
+		case SyncMove:
+			err = api.UpdatePageParentById(ctx, id, ids[action.ParentTitle])
+		case SyncAddLabel:
+			err = api.AddLabelById(ctx, id, action.Detail)
+		case SyncAttachment:
+			idx := slices.IndexFunc(action.page.Attachments, func(a DesiredAttachment) bool { return a.Title == action.Detail })
+			_, err = SyncAttachments(ctx, api, desiredAttachments(action.page.Attachments[idx:idx+1]), id, SyncAttachmentsOptions{Concurrency: 1})
+		case SyncArchive:
+			err = api.UpdatePageParentById(ctx, id, opts.ArchivePageId)
+		case SyncDelete:
+			for _, descendant := range action.descendants {
+				if err = api.DeletePage(ctx, descendant); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = api.DeletePage(ctx, id)
+			}
+		default:
+			err = errors.ErrUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("SyncTree — %s err: %w", action, err)
+		}
+	}
+	return nil
+}
+
+// applyNewPageExtras — метки и вложения только что созданной страницы
+func applyNewPageExtras(ctx context.Context, api ApiConfluence, id string, page *DesiredPage) error {
+	for _, label := range normalizeLabels(page.Labels) {
+		if err := api.AddLabelById(ctx, id, label); err != nil {
+			return err
+		}
+	}
+	if len(page.Attachments) == 0 {
+		return nil
+	}
+	_, err := SyncAttachments(ctx, api, desiredAttachments(page.Attachments), id, SyncAttachmentsOptions{})
+	return err
+}
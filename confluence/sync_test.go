@@ -0,0 +1,285 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakePage struct {
+	id, title, parentId, content string
+	labels                       []string
+	attachments                  map[string][]byte
+	comments                     map[string]string // комментарии к последним версиям вложений
+}
+
+// treeConfluence — заглушка ApiConfluence с деревом страниц в памяти
+type treeConfluence struct {
+	ApiConfluence
+	mu        sync.Mutex
+	pages     []*fakePage
+	calls     []string
+	nextId    int
+	downloads int
+}
+
+func (f *treeConfluence) page(id string) *fakePage {
+	for _, p := range f.pages {
+		if p.id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (f *treeConfluence) GetPage(_ context.Context, id string, _ GetPageOptions) (PageInfo, error) {
+	p := f.page(id)
+	return PageInfo{Id: p.id, Title: p.title, Space: &Space{Key: "DOC"}}, nil
+}
+
+func (f *treeConfluence) GetChildrenById(_ context.Context, id string, _ int) ([]PageInfo, error) {
+	var children []PageInfo
+	for _, p := range f.pages {
+		if p.parentId == id {
+			children = append(children, PageInfo{Id: p.id, Title: p.title})
+		}
+	}
+	return children, nil
+}
+
+func (f *treeConfluence) GetPagesByName(_ context.Context, name, _ string) ([]PageInfo, error) {
+	for _, p := range f.pages {
+		if p.title == name {
+			return []PageInfo{{Id: p.id, Title: p.title}}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *treeConfluence) GetContentById(_ context.Context, id string) (string, error) {
+	return f.page(id).content, nil
+}
+
+func (f *treeConfluence) GetLabelsById(_ context.Context, id string) ([]string, error) {
+	return f.page(id).labels, nil
+}
+
+func (f *treeConfluence) GetAttachments(_ context.Context, id string) ([]Attachment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := f.page(id)
+	var res []Attachment
+	for title, data := range p.attachments {
+		res = append(res, Attachment{ID: id + "/" + title, Title: title,
+			Metadata: AttachmentMetadata{Comment: p.comments[title]}, Extensions: AttachmentExtensions{FileSize: int64(len(data))}})
+	}
+	return res, nil
+}
+
+func (f *treeConfluence) DownloadAttachmentTo(_ context.Context, a Attachment, w io.Writer) error {
+	f.mu.Lock()
+	f.downloads++
+	var data []byte
+	for _, p := range f.pages {
+		if d, ok := p.attachments[a.Title]; ok && a.ID == p.id+"/"+a.Title {
+			data = d
+		}
+	}
+	f.mu.Unlock()
+	if data == nil {
+		return ErrNotFound
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func (f *treeConfluence) CreatePageWithHash(_ context.Context, name, _, content, parentPageId string) (string, error) {
+	f.nextId++
+	id := fmt.Sprint(1000 + f.nextId)
+	f.pages = append(f.pages, &fakePage{id: id, title: name, parentId: parentPageId, content: fmt.Sprintf(HideHash, hashContent(content)) + content})
+	f.calls = append(f.calls, "create "+name+" in "+parentPageId)
+	return id, nil
+}
+
+func (f *treeConfluence) UpdatePageByIdWithCheck(_ context.Context, id, content string, _ bool, _ ...UpdateOptions) error {
+	f.page(id).content = fmt.Sprintf(HideHash, hashContent(content)) + content
+	f.calls = append(f.calls, "update "+id)
+	return nil
+}
+
+func (f *treeConfluence) UpdatePageParentById(_ context.Context, id, parentId string, _ ...UpdateOptions) error {
+	f.page(id).parentId = parentId
+	f.calls = append(f.calls, "move "+id+" to "+parentId)
+	return nil
+}
+
+func (f *treeConfluence) AddLabelById(_ context.Context, id, label string) error {
+	f.page(id).labels = append(f.page(id).labels, label)
+	f.calls = append(f.calls, "label "+id+" "+label)
+	return nil
+}
+
+func (f *treeConfluence) CreateAttachmentFrom(_ context.Context, id, title string, r io.Reader, opts AttachmentOptions) error {
+	return f.storeAttachment("attach "+id+" "+title, id, title, r, opts)
+}
+
+func (f *treeConfluence) UpdateAttachmentFrom(_ context.Context, id, _, title string, r io.Reader, opts AttachmentOptions) error {
+	return f.storeAttachment("reattach "+id+" "+title, id, title, r, opts)
+}
+
+func (f *treeConfluence) storeAttachment(call, id, title string, r io.Reader, opts AttachmentOptions) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := f.page(id)
+	if p.attachments == nil {
+		p.attachments = map[string][]byte{}
+	}
+	if p.comments == nil {
+		p.comments = map[string]string{}
+	}
+	p.attachments[title], p.comments[title] = data, opts.Comment
+	f.calls = append(f.calls, call)
+	return nil
+}
+
+func (f *treeConfluence) DeletePage(_ context.Context, id string) error {
+	f.pages = slices.DeleteFunc(f.pages, func(p *fakePage) bool { return p.id == id })
+	f.calls = append(f.calls, "delete "+id)
+	return nil
+}
+
+func TestSyncTree(t *testing.T) {
+	generated := func(content string) string { return fmt.Sprintf(HideHash, hashContent(content)) + content }
+	newApi := func() *treeConfluence {
+		return &treeConfluence{pages: []*fakePage{
+			{id: "1", title: "Релиз 1.0"},
+			{id: "2", title: "API", parentId: "1", content: generated("<p>api</p>"), labels: []string{"generated"}},
+			{id: "3", title: "Методы", parentId: "1", content: generated("<p>старые методы</p>"),
+				attachments: map[string][]byte{"schema.json": []byte("{}")}},
+			{id: "4", title: "Устаревшее", parentId: "1", content: generated("<p>old</p>")},
+			{id: "5", title: "Заметки команды", parentId: "1", content: "<p>написано руками</p>"},
+		}}
+	}
+	desired := []DesiredPage{
+		{Title: "API", Content: "<p>api</p>", Labels: []string{"generated"}, Children: []DesiredPage{
+			{Title: "Методы", Content: "<p>новые методы</p>", Attachments: []DesiredAttachment{
+				{Title: "schema.json", Data: []byte(`{"v": 2}`)},
+			}},
+			{Title: "Модели", Content: "<p>модели</p>", Labels: []string{"generated"}},
+		}},
+	}
+
+	t.Run("01. Только план", func(t *testing.T) {
+		api := newApi()
+		plan, err := SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{DryRun: true, Orphans: OrphanDelete})
+		require.NoError(t, err)
+		require.Equal(t, "~ update «Методы»\n"+
+			"~ move «Методы» under «API»\n"+
+			"~ attachment «Методы»: schema.json\n"+
+			"+ create «Модели» under «API»\n"+
+			"- delete «Устаревшее»", plan.String())
+		require.Empty(t, api.calls)
+	})
+
+	t.Run("02. Применение плана", func(t *testing.T) {
+		api := newApi()
+		_, err := SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{Orphans: OrphanDelete})
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"update 3",
+			"move 3 to 2",
+			"reattach 3 schema.json",
+			"create Модели in 2",
+			"label 1001 generated",
+			"delete 4",
+		}, api.calls)
+
+		// Повторный запуск ничего не меняет и не скачивает вложения: хеш сохранён в комментарии
+		api.calls, api.downloads = nil, 0
+		plan, err := SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{Orphans: OrphanDelete})
+		require.NoError(t, err)
+		require.Empty(t, plan.Actions)
+		require.Empty(t, api.calls)
+		require.Zero(t, api.downloads)
+	})
+
+	t.Run("03. Архивирование лишних страниц", func(t *testing.T) {
+		api := newApi()
+		api.pages = append(api.pages, &fakePage{id: "9", title: "Архив"})
+		_, err := SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{Orphans: OrphanArchive, ArchivePageId: "9"})
+		require.NoError(t, err)
+		require.Contains(t, api.calls, "move 4 to 9")
+		require.Equal(t, "1", api.page("5").parentId)
+	})
+
+	t.Run("04. Страница с тем же заголовком вне дерева", func(t *testing.T) {
+		// Ручная страница не забирается в дерево
+		api := newApi()
+		api.pages = append(api.pages, &fakePage{id: "7", title: "Модели", parentId: "100", content: "<p>чужие модели</p>"})
+		_, err := SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{})
+		require.ErrorContains(t, err, `page "Модели" already exists outside the tree (pageId 7)`)
+		require.Empty(t, api.calls)
+		require.Equal(t, "<p>чужие модели</p>", api.page("7").content)
+
+		// Ручная страница с меткой автоматизации переносится
+		api.page("7").labels = []string{"automation"}
+		_, err = SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{AdoptLabel: "automation"})
+		require.NoError(t, err)
+		require.Contains(t, api.calls, "update 7")
+		require.Contains(t, api.calls, "move 7 to 2")
+
+		// Сгенерированная страница переносится
+		api = newApi()
+		api.pages = append(api.pages, &fakePage{id: "7", title: "Модели", parentId: "100", content: generated("<p>модели</p>")})
+		_, err = SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{})
+		require.NoError(t, err)
+		require.Contains(t, api.calls, "move 7 to 2")
+		require.NotContains(t, api.calls, "update 7")
+	})
+	t.Run("05. Вложенные лишние страницы", func(t *testing.T) {
+		nested := func() *treeConfluence {
+			api := newApi()
+			api.pages = append(api.pages,
+				&fakePage{id: "6", title: "Устаревшее: методы", parentId: "4", content: generated("<p>old methods</p>")},
+				&fakePage{id: "8", title: "Устаревшее: пример", parentId: "6", content: generated("<p>old example</p>")},
+				&fakePage{id: "9", title: "Архив"},
+			)
+			return api
+		}
+
+		// В архив переносится только верхняя страница, дочерние уходят вместе с ней
+		api := nested()
+		plan, err := SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{Orphans: OrphanArchive, ArchivePageId: "9"})
+		require.NoError(t, err)
+		require.Contains(t, plan.String(), "archive «Устаревшее»")
+		require.NotContains(t, plan.String(), "Устаревшее: ")
+		require.Contains(t, api.calls, "move 4 to 9")
+		require.Equal(t, "4", api.page("6").parentId)
+		require.Equal(t, "6", api.page("8").parentId)
+
+		// При удалении одно действие, дочерние страницы удаляются первыми
+		api = nested()
+		plan, err = SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{Orphans: OrphanDelete})
+		require.NoError(t, err)
+		require.Contains(t, plan.String(), "- delete «Устаревшее»")
+		require.NotContains(t, plan.String(), "Устаревшее: ")
+		require.Equal(t, []string{"delete 8", "delete 6", "delete 4"}, api.calls[len(api.calls)-3:])
+		require.Nil(t, api.page("6"))
+	})
+	t.Run("06. Метки сравниваются без учёта регистра", func(t *testing.T) {
+		api := newApi()
+		api.page("2").labels = []string{"Generated"}
+		plan, err := SyncTree(context.Background(), api, "1", desired, SyncTreeOptions{DryRun: true})
+		require.NoError(t, err)
+		require.NotContains(t, plan.String(), "label «API»")
+	})
+}
@@ -1,7 +1,15 @@
 package confluence
 
+// AutoGeneratedTitle — скрытый заголовок макроса с хешем содержимого в HideHash
+const AutoGeneratedTitle = "Эта страница сгенерирована автоматически"
+
+// AutoGeneratedSectionTitle — скрытый заголовок начала раздела, обновляемого через UpdateSection
+const AutoGeneratedSectionTitle = "Раздел сгенерирован автоматически"
+
 const hashcodePattern = `(?s).*Эта страница сгенерирована автоматически<\/ac:parameter>.*?<ac:parameter ac:name=\"atlassian-macro-output-type\">INLINE<\/ac:parameter>.*?<ac:rich-text-body>.*?<p>([0-9a-z]{32})<\/p>.*?<\/ac:rich-text-body>.*?`
 
+// Шаблоны для fmt.Sprintf. Подставляемые значения не экранируются: пользовательский текст
+// пропускайте через Escape, а новые страницы собирайте через Doc
 const (
 	CheckLine = "<hr />"
 	AutoLabel = `
@@ -170,12 +178,29 @@ const (
 `
 	HideHash = `
 <ac:structured-macro ac:name="hfl" ac:schema-version="1">
-<ac:parameter ac:name="hiddentitle">Эта страница сгенерирована автоматически</ac:parameter>
+<ac:parameter ac:name="hiddentitle">` + AutoGeneratedTitle + `</ac:parameter>
 <ac:parameter ac:name="atlassian-macro-output-type">INLINE</ac:parameter>
 <ac:rich-text-body>
 <p>%s</p>
 </ac:rich-text-body>
 </ac:structured-macro>
+`
+	// SectionStart и SectionEnd — невидимые границы раздела: название раздела, хеш его содержимого
+	SectionStart = `
+<ac:structured-macro ac:name="hfl" ac:schema-version="1">
+<ac:parameter ac:name="hiddentitle">` + AutoGeneratedSectionTitle + `</ac:parameter>
+<ac:parameter ac:name="section">%s</ac:parameter>
+<ac:parameter ac:name="atlassian-macro-output-type">INLINE</ac:parameter>
+<ac:rich-text-body>
+<p>%s</p>
+</ac:rich-text-body>
+</ac:structured-macro>
+`
+	SectionEnd = `
+<ac:structured-macro ac:name="hfl" ac:schema-version="1">
+<ac:parameter ac:name="section-end">%s</ac:parameter>
+<ac:parameter ac:name="atlassian-macro-output-type">INLINE</ac:parameter>
+</ac:structured-macro>
 `
 	HideJiraLink = `
 <ac:structured-macro ac:macro-id="e85ae079-45f9-4e76-82ec-7ba5f203a8ac" ac:name="hfl" ac:schema-version="1">
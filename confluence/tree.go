@@ -0,0 +1,143 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/carlmjohnson/requests"
+)
+
+const defaultTreeConcurrency = 4
+
+// PageTree — страница вместе с дочерними. Depth — уровень относительно корня дерева, у корня 0
+type PageTree struct {
+	PageInfo
+	Depth    int
+	Children []*PageTree
+}
+
+// Descendants — все страницы под узлом плоским списком, каждая страница перед своими дочерними
+func (t *PageTree) Descendants() []PageInfo {
+	var res []PageInfo
+	for _, child := range t.Children {
+		res = append(res, child.PageInfo)
+		res = append(res, child.Descendants()...)
+	}
+	return res
+}
+
+// PageTreeOptions — параметры обхода дерева
+type PageTreeOptions struct {
+	MaxDepth    int // глубина обхода, 0 — без ограничения
+	Concurrency int // одновременных запросов, по умолчанию 4
+	PageSize    int // размер страницы запроса дочерних страниц
+}
+
+// GetPageTree — дерево страниц под id. Уровни обходятся параллельно, не больше opts.Concurrency запросов
+// одновременно. На каждую страницу дерева — один запрос её дочерних страниц, с догрузкой следующих страниц
+func (c *confluence) GetPageTree(ctx context.Context, id string, opts PageTreeOptions) (*PageTree, error) {
+	root, err := c.GetPage(ctx, id, GetPageOptions{Expand: []string{ExpandSpace}})
+	if err != nil {
+		return nil, fmt.Errorf("GetPageTree — get confluence pageId %s err: %w", id, err)
+	}
+	tree := &PageTree{PageInfo: root}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultTreeConcurrency
+	}
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		sem      = make(chan struct{}, opts.Concurrency)
+	)
+	var visit func(node *PageTree)
+	visit = func(node *PageTree) {
+		defer wg.Done()
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		children, err := c.GetChildrenById(ctx, node.Id, opts.PageSize)
+		<-sem
+		if err != nil {
+			once.Do(func() {
+				firstErr = err
+				cancel()
+			})
+			return
+		}
+		// Каждая горутина заполняет только свой узел, поэтому блокировка не нужна
+		node.Children = make([]*PageTree, 0, len(children))
+		for _, child := range children {
+			childNode := &PageTree{PageInfo: child, Depth: node.Depth + 1}
+			node.Children = append(node.Children, childNode)
+			if opts.MaxDepth > 0 && childNode.Depth >= opts.MaxDepth {
+				continue
+			}
+			wg.Add(1)
+			go visit(childNode)
+		}
+	}
+	wg.Add(1)
+	visit(tree)
+	wg.Wait()
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	if firstErr != nil {
+		return nil, fmt.Errorf("GetPageTree — get confluence pageId %s tree err: %w", id, firstErr)
+	}
+	return tree, nil
+}
+
+// GetPageTreeByDescendants — дерево страниц под id за один постраничный запрос /descendant/page.
+// Родитель каждой страницы определяется по её предкам. Эндпоинт есть не во всех версиях Confluence,
+// иначе следует использовать GetPageTree
+func (c *confluence) GetPageTreeByDescendants(ctx context.Context, id string, pageSize int) (*PageTree, error) {
+	root, err := c.GetPage(ctx, id, GetPageOptions{Expand: []string{ExpandSpace}})
+	if err != nil {
+		return nil, fmt.Errorf("GetPageTreeByDescendants — get confluence pageId %s err: %w", id, err)
+	}
+	builder := requests.
+		URL(fmt.Sprintf("%s/%s/descendant/page", c.baseUrl, id)).
+		Param("expand", ExpandAncestors)
+	descendants, err := collectAll(paginate[PageInfo](ctx, c, builder, pageSize))
+	if err != nil {
+		return nil, fmt.Errorf("GetPageTreeByDescendants — get confluence pageId %s descendants err: %w", id, err)
+	}
+	return buildPageTree(root, descendants)
+}
+
+// buildPageTree — дерево из плоского списка страниц с предками. Порядок дочерних страниц сохраняется
+func buildPageTree(root PageInfo, pages []PageInfo) (*PageTree, error) {
+	tree := &PageTree{PageInfo: root}
+	nodes := map[string]*PageTree{root.Id: tree}
+	for _, page := range pages {
+		nodes[page.Id] = &PageTree{PageInfo: page}
+	}
+	// Сначала все узлы, потому что потомок может прийти раньше своего родителя
+	for _, page := range pages {
+		if len(page.Parents) == 0 {
+			return nil, fmt.Errorf("pageId %s has no ancestors", page.Id)
+		}
+		parent, ok := nodes[page.Parents[len(page.Parents)-1].Id]
+		if !ok {
+			return nil, fmt.Errorf("parent of pageId %s not found in tree", page.Id)
+		}
+		parent.Children = append(parent.Children, nodes[page.Id])
+	}
+	setDepth(tree, 0)
+	return tree, nil
+}
+
+func setDepth(node *PageTree, depth int) {
+	node.Depth = depth
+	for _, child := range node.Children {
+		setDepth(child, depth+1)
+	}
+}
@@ -0,0 +1,123 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTreeServer — Confluence с деревом страниц: 1 → (2 → (4, 5), 3 → 6), 6 → 7
+func newTreeServer(t *testing.T) (ApiConfluence, *atomic.Int32) {
+	parents := map[string]string{"2": "1", "3": "1", "4": "2", "5": "2", "6": "3", "7": "6"}
+	order := []string{"2", "3", "4", "5", "6", "7"}
+	ancestors := func(id string) []PageInfo {
+		var res []PageInfo
+		for p := parents[id]; p != ""; p = parents[p] {
+			res = append([]PageInfo{{Id: p}}, res...)
+		}
+		return res
+	}
+	page := func(ids []string, r *http.Request) searchPagesResponse {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		resp := searchPagesResponse{Start: start, Limit: limit}
+		for _, id := range ids[min(start, len(ids)):min(start+limit, len(ids))] {
+			info := PageInfo{Id: id, Title: "Страница " + id}
+			if r.URL.Query().Get("expand") == ExpandAncestors {
+				info.Parents = ancestors(id)
+			}
+			resp.Results = append(resp.Results, info)
+		}
+		resp.Size = len(resp.Results)
+		return resp
+	}
+
+	var childRequests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/rest/api/content/"), "/")
+		var resp any
+		switch {
+		case len(parts) == 1:
+			resp = PageInfo{Id: parts[0], Title: "Страница " + parts[0]}
+		case parts[1] == "child":
+			childRequests.Add(1)
+			var ids []string
+			for _, id := range order {
+				if parents[id] == parts[0] {
+					ids = append(ids, id)
+				}
+			}
+			resp = page(ids, r)
+		case parts[1] == "descendant":
+			// Потомки приходят не по порядку дерева
+			resp = page([]string{"7", "4", "2", "6", "5", "3"}, r)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return NewConfluence(srv.URL+"/rest/api/content", "user", "password"), &childRequests
+}
+
+// treeIds — id страниц дерева с отступом по глубине
+func treeIds(tree *PageTree) []string {
+	res := []string{strings.Repeat(" ", tree.Depth) + tree.Id}
+	for _, child := range tree.Children {
+		res = append(res, treeIds(child)...)
+	}
+	return res
+}
+
+func TestGetPageTree(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("01. Дочерние страницы догружаются постранично", func(t *testing.T) {
+		c, _ := newTreeServer(t)
+		children, err := c.GetChildrenById(ctx, "2", 1)
+		require.NoError(t, err)
+		require.Len(t, children, 2)
+	})
+
+	t.Run("02. Дерево с глубиной", func(t *testing.T) {
+		c, requests := newTreeServer(t)
+		tree, err := c.GetPageTree(ctx, "1", PageTreeOptions{Concurrency: 2, PageSize: 1})
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", " 2", "  4", "  5", " 3", "  6", "   7"}, treeIds(tree))
+		require.Equal(t, "Страница 1", tree.Title)
+		// По запросу на каждую дочернюю страницу при размере страницы 1 и завершающий пустой запрос
+		require.EqualValues(t, 6+7, requests.Load())
+	})
+
+	t.Run("03. Ограничение глубины", func(t *testing.T) {
+		c, _ := newTreeServer(t)
+		tree, err := c.GetPageTree(ctx, "1", PageTreeOptions{MaxDepth: 2})
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", " 2", "  4", "  5", " 3", "  6"}, treeIds(tree))
+	})
+
+	t.Run("04. Плоский список", func(t *testing.T) {
+		c, _ := newTreeServer(t)
+		pages, err := c.GetChildrenByIdRecursive(ctx, "1", 500)
+		require.NoError(t, err)
+		var ids []string
+		for _, page := range pages {
+			ids = append(ids, page.Id)
+		}
+		require.Equal(t, []string{"2", "4", "5", "3", "6", "7"}, ids)
+	})
+
+	t.Run("05. Дерево по потомкам", func(t *testing.T) {
+		c, requests := newTreeServer(t)
+		tree, err := c.GetPageTreeByDescendants(ctx, "1", 4)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1", " 2", "  4", "  5", " 3", "  6", "   7"}, treeIds(tree))
+		require.Zero(t, requests.Load())
+	})
+}
@@ -1,15 +1,25 @@
 package confluence
 
 import (
+	"cmp"
+	"crypto/md5"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
+	"strings"
 )
 
 var ErrNotFound = errors.New("confluence: data not found")
 
+// ErrVersionConflict — страницу изменили одновременно с нами, и повторные попытки обновления закончились
+var ErrVersionConflict = errors.New("confluence: page version conflict")
+
+// maxUpdateAttempts — сколько раз пытаться обновить страницу при конфликте версий
+const maxUpdateAttempts = 3
+
 func validateStatus(resp *http.Response) error {
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 		return nil
@@ -24,10 +34,73 @@ func validateStatus(resp *http.Response) error {
 	return fmt.Errorf("status code %v.\nBody:%s", resp.StatusCode, string(b))
 }
 
+// validatePageUpdate — validateStatus для обновления страницы: 409 означает, что номер версии устарел
+func validatePageUpdate(resp *http.Response) error {
+	if resp.StatusCode != http.StatusConflict {
+		return validateStatus(resp)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrVersionConflict, string(b))
+}
+
+// hashContent — хеш содержимого для HideHash и SectionStart
+func hashContent(content string) string {
+	hash := md5.Sum([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
+
+// extractHashcodeFromContent — хеш из первого макроса HideHash на странице
 func extractHashcodeFromContent(content string) string {
+	doc, err := ParseStorage(content)
+	if err != nil {
+		return extractHashcodeByPattern(content)
+	}
+	for _, macro := range doc.MacrosByParam("", "hiddentitle", AutoGeneratedTitle) {
+		body := macro.MacroBody()
+		if body == nil {
+			continue
+		}
+		if hash := strings.TrimSpace(body.Text()); isHashcode(hash) {
+			return hash
+		}
+	}
+	return ""
+}
+
+// extractHashcodeByPattern — поиск хеша регулярным выражением, если документ не удалось разобрать
+func extractHashcodeByPattern(content string) string {
 	match := regexp.MustCompile(hashcodePattern).FindStringSubmatch(content)
 	if len(match) > 1 {
 		return match[1]
 	}
 	return ""
 }
+
+func isHashcode(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	for _, c := range s {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeUpdateOptions — параметры из необязательного аргумента, последние непустые значения важнее
+func mergeUpdateOptions(opts []UpdateOptions) UpdateOptions {
+	var res UpdateOptions
+	for _, opt := range opts {
+		res.Message = cmp.Or(opt.Message, res.Message)
+		res.MinorEdit = res.MinorEdit || opt.MinorEdit
+	}
+	return res
+}
+
+func (o UpdateOptions) version() *PageVersion {
+	return &PageVersion{Message: o.Message, MinorEdit: o.MinorEdit}
+}
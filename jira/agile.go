@@ -0,0 +1,201 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// Agile API живёт рядом с REST API, но под своим префиксом: /rest/agile/1.0
+const (
+	restApiPath   = "/rest/api/2"
+	restApiV3Path = "/rest/api/3"
+	restAgilePath = "/rest/agile/1.0"
+)
+
+// Jira принимает не больше 50 задач в одном запросе на перенос в спринт/бэклог и ранжирование
+const agileIssuesChunkSize = 50
+
+// agileUrl — базовый URL Agile API, вычисляется из BaseUrl вида https://host/rest/api/2 (или /3 в Jira Cloud)
+func (j *jira) agileUrl() string {
+	return strings.TrimSuffix(strings.TrimSuffix(j.BaseUrl, restApiPath), restApiV3Path) + restAgilePath
+}
+
+// GetBoards — возвращает все доски, привязанные к проекту. Если projectKey пустой, возвращает все доски
+func (j *jira) GetBoards(ctx context.Context, projectKey string) ([]Board, error) {
+	var all []Board
+	for offset := 0; ; {
+		var resp agilePage[Board]
+		req := requests.
+			URL(fmt.Sprintf("%s/board", j.agileUrl())).
+			Param("startAt", strconv.Itoa(offset)).
+			Config(j.config).
+			ToDeserializer(j.unmarshal, &resp).
+			AddValidator(validateStatus)
+		if projectKey != "" {
+			req.Param("projectKeyOrId", projectKey)
+		}
+		if err := req.Fetch(ctx); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Values...)
+		offset += len(resp.Values)
+		if resp.IsLast || len(resp.Values) == 0 {
+			return all, nil
+		}
+	}
+}
+
+// GetBoardSprints — возвращает спринты доски, states фильтрует по состоянию (SprintState)
+func (j *jira) GetBoardSprints(ctx context.Context, boardId int, states ...string) ([]Sprint, error) {
+	var all []Sprint
+	for offset := 0; ; {
+		var resp agilePage[Sprint]
+		req := requests.
+			URL(fmt.Sprintf("%s/board/%d/sprint", j.agileUrl(), boardId)).
+			Param("startAt", strconv.Itoa(offset)).
+			Config(j.config).
+			ToDeserializer(j.unmarshal, &resp).
+			AddValidator(validateStatus)
+		if len(states) > 0 {
+			req.Param("state", strings.Join(states, ","))
+		}
+		if err := req.Fetch(ctx); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Values...)
+		offset += len(resp.Values)
+		if resp.IsLast || len(resp.Values) == 0 {
+			return all, nil
+		}
+	}
+}
+
+// GetActiveSprint — возвращает активный спринт доски
+func (j *jira) GetActiveSprint(ctx context.Context, boardId int) (Sprint, error) {
+	sprints, err := j.GetBoardSprints(ctx, boardId, SprintState.Active)
+	if err != nil {
+		return Sprint{}, err
+	}
+	if len(sprints) == 0 {
+		return Sprint{}, fmt.Errorf("board %d has no active sprint", boardId)
+	}
+	return sprints[0], nil
+}
+
+// GetSprintIssues — возвращает все задачи спринта
+func (j *jira) GetSprintIssues(ctx context.Context, sprintId int, fields ...string) ([]IssueJira, error) {
+	var all []IssueJira
+	for offset := 0; ; {
+		var resp SearchResponse
+		req := requests.
+			URL(fmt.Sprintf("%s/sprint/%d/issue", j.agileUrl(), sprintId)).
+			Param("startAt", strconv.Itoa(offset)).
+			Config(j.config).
+			ToDeserializer(j.unmarshal, &resp).
+			AddValidator(validateStatus)
+		if len(fields) > 0 {
+			req.Param("fields", strings.Join(fields, ","))
+		}
+		if err := req.Fetch(ctx); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Issues...)
+		offset += len(resp.Issues)
+		if len(resp.Issues) == 0 || offset >= resp.Total {
+			return all, nil
+		}
+	}
+}
+
+// MoveIssuesToSprint — переносит задачи в спринт
+func (j *jira) MoveIssuesToSprint(ctx context.Context, sprintId int, issueKeys ...string) error {
+	if len(issueKeys) == 0 {
+		return fmt.Errorf("issueKeys are empty")
+	}
+	for chunk := range slices.Chunk(issueKeys, agileIssuesChunkSize) {
+		err := requests.
+			URL(fmt.Sprintf("%s/sprint/%d/issue", j.agileUrl(), sprintId)).
+			Post().
+			Config(j.config).
+			BodySerializer(j.marshal, MoveIssuesRequest{Issues: chunk}).
+			AddValidator(validateStatus).
+			Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to move issues to sprint %d: %w", sprintId, err)
+		}
+	}
+	return nil
+}
+
+// MoveIssuesToBacklog — убирает задачи из спринтов в бэклог доски
+func (j *jira) MoveIssuesToBacklog(ctx context.Context, issueKeys ...string) error {
+	if len(issueKeys) == 0 {
+		return fmt.Errorf("issueKeys are empty")
+	}
+	for chunk := range slices.Chunk(issueKeys, agileIssuesChunkSize) {
+		err := requests.
+			URL(fmt.Sprintf("%s/backlog/issue", j.agileUrl())).
+			Post().
+			Config(j.config).
+			BodySerializer(j.marshal, MoveIssuesRequest{Issues: chunk}).
+			AddValidator(validateStatus).
+			Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to move issues to backlog: %w", err)
+		}
+	}
+	return nil
+}
+
+// RankIssues — ставит задачи перед rankBeforeIssue или, если он пустой, после rankAfterIssue.
+// Порядок задач в issueKeys сохраняется
+func (j *jira) RankIssues(ctx context.Context, issueKeys []string, rankBeforeIssue, rankAfterIssue string) error {
+	if len(issueKeys) == 0 {
+		return fmt.Errorf("issueKeys are empty")
+	}
+	if rankBeforeIssue == "" && rankAfterIssue == "" {
+		return fmt.Errorf("rankBeforeIssue or rankAfterIssue must be set")
+	}
+	for chunk := range slices.Chunk(issueKeys, agileIssuesChunkSize) {
+		req := RankIssuesRequest{Issues: chunk}
+		if rankBeforeIssue != "" {
+			req.RankBeforeIssue = rankBeforeIssue
+		} else {
+			req.RankAfterIssue = rankAfterIssue
+			// Следующая пачка встаёт за последней задачей предыдущей, чтобы не перемешать порядок
+			rankAfterIssue = chunk[len(chunk)-1]
+		}
+		err := requests.
+			URL(fmt.Sprintf("%s/issue/rank", j.agileUrl())).
+			Put().
+			Config(j.config).
+			BodySerializer(j.marshal, req).
+			AddValidator(validateStatus).
+			Fetch(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to rank issues: %w", err)
+		}
+	}
+	return nil
+}
+
+// PlanIssueToActiveSprint — переводит задачу в статус Selected (переход FromRateToSelected)
+// и добавляет её в активный спринт доски
+func (j *jira) PlanIssueToActiveSprint(ctx context.Context, issueKey string, boardId int) error {
+	if strings.TrimSpace(issueKey) == "" {
+		return fmt.Errorf("issueKey is empty")
+	}
+	sprint, err := j.GetActiveSprint(ctx, boardId)
+	if err != nil {
+		return err
+	}
+	if err := j.TransitionToStatus(ctx, issueKey, Issue.Status.Selected); err != nil {
+		return err
+	}
+	return j.MoveIssuesToSprint(ctx, sprint.ID, issueKey)
+}
@@ -0,0 +1,79 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgileUrl(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseUrl string
+		want    string
+	}{
+		{name: "01. REST API v2", baseUrl: "https://jira.example.com/rest/api/2", want: "https://jira.example.com/rest/agile/1.0"},
+		{name: "02. Без префикса API", baseUrl: "http://127.0.0.1:8080", want: "http://127.0.0.1:8080/rest/agile/1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &jira{BaseUrl: tt.baseUrl}
+			require.Equal(t, tt.want, j.agileUrl())
+		})
+	}
+}
+
+func TestGetBoardSprintsPaging(t *testing.T) {
+	const total = 7
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/agile/1.0/board/42/sprint", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, SprintState.Active, r.URL.Query().Get("state"))
+		start, _ := strconv.Atoi(r.URL.Query().Get("startAt"))
+		// Отдаём по 3 спринта за запрос
+		end := min(start+3, total)
+		page := agilePage[Sprint]{StartAt: start, MaxResults: 3, IsLast: end == total}
+		for i := start; i < end; i++ {
+			page.Values = append(page.Values, Sprint{ID: i + 1, State: SprintState.Active})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(page))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	j := &jira{BaseUrl: srv.URL + restApiPath, Token: "token"}
+	got, err := j.GetBoardSprints(context.Background(), 42, SprintState.Active)
+	require.NoError(t, err)
+	require.Len(t, got, total)
+	require.Equal(t, total, got[total-1].ID)
+}
+
+func TestMoveIssuesToSprintChunks(t *testing.T) {
+	var received [][]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/agile/1.0/sprint/7/issue", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		var req MoveIssuesRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		received = append(received, req.Issues)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	keys := make([]string, 0, 120)
+	for i := 1; i <= 120; i++ {
+		keys = append(keys, "KEY-"+strconv.Itoa(i))
+	}
+	j := &jira{BaseUrl: srv.URL, Token: "token"}
+	require.NoError(t, j.MoveIssuesToSprint(context.Background(), 7, keys...))
+	require.Len(t, received, 3)
+	require.Len(t, received[0], agileIssuesChunkSize)
+	require.Len(t, received[2], 20)
+	require.Error(t, j.MoveIssuesToSprint(context.Background(), 7))
+}
@@ -0,0 +1,195 @@
+package jira
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// Поля, которые не переносятся значением: служебные, связи и вложения (вложения копируются отдельно)
+var cloneSkipFields = []string{
+	Issue.Fields.Project,
+	Issue.Fields.IssueType,
+	Issue.Fields.Attachments,
+	Issue.Fields.IssueLinks,
+	Issue.Fields.Parent,
+	Issue.Fields.SubTasks,
+	Issue.Fields.Status,
+	Issue.Fields.Resolution,
+}
+
+type CloneOptions struct {
+	CopyAttachments   bool   // скопировать вложения
+	LinkType          string // тип связи копии с исходной задачей, например LinkType.Cloners. Пустой — без связи
+	Comment           bool   // оставить комментарии со ссылками в обеих задачах
+	CloseSourceStatus string // перевести исходную задачу в этот статус — перенос задачи в другой проект
+}
+
+// CloneResult — результат копирования задачи
+type CloneResult struct {
+	Created     CreatedIssueResponse
+	Copied      []string       // перенесённые поля (идентификаторы в целевом проекте)
+	Dropped     []FieldProblem // поля, которые не удалось перенести, с причиной
+	Attachments int            // количество скопированных вложений
+}
+
+// CloneIssue — копирует задачу в другой проект и/или с другим типом. Переносятся поля, которые есть
+// на экране создания целевого проекта; значения из списков сопоставляются по названию.
+// fieldMapping переименовывает поля: идентификатор в исходном проекте → идентификатор в целевом
+func (j *jira) CloneIssue(ctx context.Context, issueKey, targetProject, targetTypeId string, fieldMapping map[string]string, opts CloneOptions) (CloneResult, error) {
+	if strings.TrimSpace(issueKey) == "" {
+		return CloneResult{}, fmt.Errorf("issueKey is empty")
+	}
+	source, err := j.getIssueRow(ctx, issueKey)
+	if err != nil {
+		return CloneResult{}, fmt.Errorf("failed to get source issue %s: %w", issueKey, err)
+	}
+	meta, err := j.GetIssueTypeMeta(ctx, targetProject, targetTypeId)
+	if err != nil {
+		return CloneResult{}, fmt.Errorf("failed to get create meta: %w", err)
+	}
+
+	var res CloneResult
+	fields, dropped := translateCloneFields(meta, source.Fields, fieldMapping)
+	res.Dropped = dropped
+	fields[Issue.Fields.Project] = map[string]any{"key": targetProject}
+	fields[Issue.Fields.IssueType] = map[string]any{"id": targetTypeId}
+	for fieldId := range fields {
+		if fieldId != Issue.Fields.Project && fieldId != Issue.Fields.IssueType {
+			res.Copied = append(res.Copied, fieldId)
+		}
+	}
+	slices.Sort(res.Copied)
+	if problems := meta.Validate(fields); len(problems) > 0 {
+		return res, ValidationError{Problems: problems}
+	}
+
+	res.Created, err = j.CreateIssueFromMap(ctx, fields)
+	if err != nil {
+		return res, fmt.Errorf("failed to create clone of %s: %w", issueKey, err)
+	}
+	newKey := res.Created.Key
+
+	if opts.CopyAttachments {
+		attachments, err := rowAttachments(source)
+		if err != nil {
+			return res, err
+		}
+		for _, attachment := range attachments {
+			data, err := j.DownloadAttachment(ctx, attachment)
+			if err != nil {
+				return res, fmt.Errorf("failed to download attachment %s: %w", attachment.Filename, err)
+			}
+			if _, err := j.AddAttachment(ctx, newKey, attachment.Filename, data); err != nil {
+				return res, fmt.Errorf("failed to upload attachment %s: %w", attachment.Filename, err)
+			}
+			res.Attachments++
+		}
+	}
+	if opts.LinkType != "" {
+		if err := j.LinkIssues(ctx, opts.LinkType, newKey, issueKey); err != nil {
+			return res, fmt.Errorf("failed to link %s with %s: %w", newKey, issueKey, err)
+		}
+	}
+	if opts.Comment {
+		if err := j.CommentIssue(ctx, issueKey, fmt.Sprintf("Создана копия задачи: %s", newKey)); err != nil {
+			return res, err
+		}
+		if err := j.CommentIssue(ctx, newKey, fmt.Sprintf("Скопировано из задачи: %s", issueKey)); err != nil {
+			return res, err
+		}
+	}
+	if opts.CloseSourceStatus != "" {
+		if err := j.TransitionToStatus(ctx, issueKey, opts.CloseSourceStatus); err != nil {
+			return res, fmt.Errorf("failed to close source issue %s: %w", issueKey, err)
+		}
+	}
+	return res, nil
+}
+
+// getIssueRow — задача вместе с сырыми полями, включая кастомные
+func (j *jira) getIssueRow(ctx context.Context, issueKey string) (ExportRow, error) {
+	var raw json.RawMessage
+	err := requests.
+		URL(fmt.Sprintf("%s/issue/%s", j.BaseUrl, issueKey)).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &raw).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return ExportRow{}, err
+	}
+	return newExportRow(raw)
+}
+
+// rowAttachments — вложения задачи из сырых полей
+func rowAttachments(row ExportRow) ([]Attachment, error) {
+	raw, ok := row.Fields[Issue.Fields.Attachments]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var attachments []Attachment
+	if err := json.Unmarshal(b, &attachments); err != nil {
+		return nil, fmt.Errorf("failed to parse attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// translateCloneFields — переводит сырые поля исходной задачи в тело создания задачи по метаданным целевого проекта
+func translateCloneFields(meta *IssueTypeMeta, source map[string]any, fieldMapping map[string]string) (map[string]any, []FieldProblem) {
+	fields := map[string]any{}
+	var dropped []FieldProblem
+	for sourceId, value := range source {
+		if slices.Contains(cloneSkipFields, sourceId) || isEmptyFieldValue(value) {
+			continue
+		}
+		targetId := cmp.Or(fieldMapping[sourceId], sourceId)
+		field, ok := meta.FindField(targetId)
+		if !ok {
+			// Большинство полей ответа (created, updated, votes...) не редактируются, о них не сообщаем
+			if _, mapped := fieldMapping[sourceId]; mapped || strings.HasPrefix(sourceId, "customfield_") {
+				dropped = append(dropped, FieldProblem{FieldID: sourceId, Name: sourceId, Message: "field is not on the target create screen"})
+			}
+			continue
+		}
+		built, problem := field.buildValue(humanizeFieldValue(value))
+		if problem != nil {
+			problem.FieldID = sourceId
+			dropped = append(dropped, *problem)
+			continue
+		}
+		fields[targetId] = built
+	}
+	slices.SortStableFunc(dropped, func(a, b FieldProblem) int { return strings.Compare(a.FieldID, b.FieldID) })
+	return fields, dropped
+}
+
+// humanizeFieldValue — значение поля в виде, понятном MetaField.buildValue: значения из списков,
+// компоненты, версии и пользователи заменяются их названием (логином), чтобы найти их заново в целевом проекте
+func humanizeFieldValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range []string{"accountId", "value", "name", "key"} {
+			if s, ok := v[key].(string); ok && s != "" {
+				return s
+			}
+		}
+		return v
+	case []any:
+		res := make([]any, 0, len(v))
+		for _, item := range v {
+			res = append(res, humanizeFieldValue(item))
+		}
+		return res
+	}
+	return value
+}
@@ -0,0 +1,119 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateCloneFields(t *testing.T) {
+	source := map[string]any{
+		Issue.Fields.Summary:    "Не работает выгрузка",
+		Issue.Fields.Status:     map[string]any{"id": Issue.Status.New, "name": "Новый"},
+		Issue.Fields.Customer:   map[string]any{"id": "555", "value": "Сбербанк"},
+		Issue.Fields.Components: []any{map[string]any{"id": "1", "name": "Jira"}, map[string]any{"id": "2", "name": "Почта"}},
+		Issue.Fields.Assignee:   map[string]any{"name": "testuser", "displayName": "Test User"},
+		Issue.Fields.Labels:     []any{"support"},
+		Issue.Fields.ProductSup: map[string]any{"id": "15545", "value": "CDI"},
+		"customfield_10000":     "Бизнес-описание",
+		"created":               "2025-01-17T12:03:44.000+0300",
+	}
+	mapping := map[string]string{Issue.Fields.ProductSup: "customfield_20000"}
+
+	fields, dropped := translateCloneFields(testCreateMeta(), source, mapping)
+	require.Equal(t, map[string]any{
+		Issue.Fields.Summary:  "Не работает выгрузка",
+		Issue.Fields.Customer: map[string]any{"id": "12669"},
+		Issue.Fields.Assignee: map[string]any{"name": "testuser"},
+	}, fields)
+
+	var droppedIds []string
+	for _, p := range dropped {
+		droppedIds = append(droppedIds, p.FieldID)
+	}
+	// Компонента «Почта» нет в целевом проекте, продукт и бизнес-описание некуда переносить
+	require.Equal(t, []string{Issue.Fields.Components, "customfield_10000", Issue.Fields.ProductSup}, droppedIds)
+	require.Contains(t, dropped[0].Message, "Почта")
+}
+
+func TestCloneIssue(t *testing.T) {
+	var (
+		created  map[string]any
+		uploaded []string
+		calls    []string
+	)
+	mux := http.NewServeMux()
+	var srvUrl string
+	mux.HandleFunc("GET /issue/SRC-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{"key": "SRC-1", "fields": map[string]any{
+			Issue.Fields.Summary:  "Не работает выгрузка",
+			Issue.Fields.Customer: map[string]any{"id": "555", "value": "Сбербанк"},
+			Issue.Fields.Attachments: []any{
+				map[string]any{"id": "1", "filename": "log.txt", "content": srvUrl + "/secure/attachment/1/log.txt"},
+			},
+		}}))
+	})
+	mux.HandleFunc("GET /issue/createmeta/NEW/issuetypes/10001", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(testCreateMeta()))
+	})
+	mux.HandleFunc("POST /issue", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Fields map[string]any `json:"fields"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		created = req.Fields
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": "100", "key": "NEW-1"}`))
+	})
+	mux.HandleFunc("GET /secure/attachment/1/log.txt", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("содержимое лога"))
+	})
+	mux.HandleFunc("POST /issue/NEW-1/attachments", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "no-check", r.Header.Get("X-Atlassian-Token"))
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		data, err := io.ReadAll(file)
+		require.NoError(t, err)
+		uploaded = append(uploaded, header.Filename+": "+string(data))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id": "2", "filename": "log.txt"}]`))
+	})
+	mux.HandleFunc("POST /issueLink", func(w http.ResponseWriter, r *http.Request) {
+		var req IssueLinkRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		calls = append(calls, fmt.Sprintf("link %s %s %s", req.InwardIssue.Key, req.Type.Name, req.OutwardIssue.Key))
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("POST /issue/{key}/comment", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "comment "+r.PathValue("key"))
+		w.WriteHeader(http.StatusCreated)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	srvUrl = srv.URL
+	j := &jira{BaseUrl: srv.URL, Token: "token"}
+
+	res, err := j.CloneIssue(context.Background(), "SRC-1", "NEW", "10001", nil,
+		CloneOptions{CopyAttachments: true, LinkType: "Cloners", Comment: true})
+	require.NoError(t, err)
+	require.Equal(t, "NEW-1", res.Created.Key)
+	require.Equal(t, []string{Issue.Fields.Customer, Issue.Fields.Summary}, res.Copied)
+	require.Equal(t, 1, res.Attachments)
+	require.Equal(t, map[string]any{
+		Issue.Fields.Summary:   "Не работает выгрузка",
+		Issue.Fields.Customer:  map[string]any{"id": "12669"},
+		Issue.Fields.Project:   map[string]any{"key": "NEW"},
+		Issue.Fields.IssueType: map[string]any{"id": "10001"},
+	}, created)
+	require.Equal(t, []string{"log.txt: содержимое лога"}, uploaded)
+	require.Equal(t, []string{"link NEW-1 Cloners SRC-1", "comment SRC-1", "comment NEW-1"}, calls)
+}
@@ -0,0 +1,351 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+// Jira Cloud отличается от Server/DC:
+//   - пользователи идентифицируются по accountId, а не по name/key;
+//   - поиск — /search/jql с пагинацией по nextPageToken, без startAt и total;
+//   - описание задачи и тело комментария — документы ADF (Atlassian Document Format), а не строки.
+// Эти различия скрыты за теми же методами ApiJira: строки конвертируются в ADF при отправке и обратно при чтении.
+
+// cloudSearchPageSize — максимальный размер страницы /search/jql
+const cloudSearchPageSize = 100
+
+// errStopCloudSearch — досрочное завершение обхода страниц, когда нужная страница уже набрана
+var errStopCloudSearch = errors.New("stop cloud search")
+
+type cloudSearchRequest struct {
+	Jql           string   `json:"jql"`
+	NextPageToken string   `json:"nextPageToken,omitzero"`
+	MaxResults    int      `json:"maxResults,omitzero"`
+	Fields        []string `json:"fields,omitzero"`
+	Expand        string   `json:"expand,omitzero"`
+}
+
+type cloudSearchResponse[T any] struct {
+	Issues        []T    `json:"issues"`
+	NextPageToken string `json:"nextPageToken,omitzero"`
+	IsLast        bool   `json:"isLast"`
+}
+
+// cloudDefaultFields — поля по умолчанию. Без явного списка /search/jql возвращает только id задач,
+// а /search в Server/DC — все навигационные поля
+var cloudDefaultFields = []string{"*navigable"}
+
+// searchCloudPages — обходит все страницы /search/jql, передавая задачи каждой страницы в fn
+func searchCloudPages[T any](ctx context.Context, j *jira, req SearchRequest, fn func(issues []T) error) error {
+	return searchCloudPagesFrom(ctx, j, req, "", func(issues []T, _ string) error {
+		return fn(issues)
+	})
+}
+
+// searchCloudPagesFrom — обход страниц /search/jql начиная с pageToken (пустой — с начала выборки).
+// fn получает задачи страницы и токен следующей страницы
+func searchCloudPagesFrom[T any](ctx context.Context, j *jira, req SearchRequest, pageToken string, fn func(issues []T, nextPageToken string) error) error {
+	cloudReq := cloudSearchRequest{
+		Jql:           req.Jql,
+		NextPageToken: pageToken,
+		MaxResults:    min(req.MaxResults, cloudSearchPageSize),
+		Fields:        req.Fields,
+		Expand:        strings.Join(req.Expand, ","),
+	}
+	if cloudReq.MaxResults == 0 {
+		cloudReq.MaxResults = cloudSearchPageSize
+	}
+	if len(cloudReq.Fields) == 0 {
+		cloudReq.Fields = cloudDefaultFields
+	}
+	for {
+		var resp cloudSearchResponse[T]
+		err := requests.
+			URL(fmt.Sprintf("%s/search/jql", j.BaseUrl)).
+			Config(j.config).
+			BodySerializer(j.marshal, &cloudReq).
+			ToDeserializer(j.unmarshal, &resp).
+			AddValidator(validateStatus).
+			Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		if resp.IsLast || len(resp.Issues) == 0 {
+			resp.NextPageToken = ""
+		}
+		if err := fn(resp.Issues, resp.NextPageToken); err != nil {
+			return err
+		}
+		if resp.NextPageToken == "" {
+			return nil
+		}
+		cloudReq.NextPageToken = resp.NextPageToken
+	}
+}
+
+// cloudTokenKey — позиция в выборке, с которой начинается страница nextPageToken
+type cloudTokenKey struct {
+	query    string
+	fields   string
+	pageSize int
+	offset   int
+}
+
+const (
+	cloudTokenTTL  = 10 * time.Minute // сколько хранится токен следующей страницы
+	maxCloudTokens = 1000             // сколько токенов хранится одновременно
+)
+
+// cloudTokenCache — токены следующих страниц для searchTasksCloud. Циклы, прерванные до конца выборки,
+// и разовые вызовы оставляют неиспользованные токены, поэтому кеш ограничен по времени жизни и размеру
+type cloudTokenCache struct {
+	mu      sync.Mutex
+	entries map[cloudTokenKey]cloudToken
+}
+
+type cloudToken struct {
+	token  string
+	stored time.Time
+}
+
+// take — забрать токен: токен одноразовый и из кеша удаляется
+func (c *cloudTokenCache) take(key cloudTokenKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	delete(c.entries, key)
+	if time.Since(entry.stored) > cloudTokenTTL {
+		return "", false
+	}
+	return entry.token, true
+}
+
+// store — запомнить токен, заодно удалив устаревшие. При переполнении вытесняется самый старый
+func (c *cloudTokenCache) store(key cloudTokenKey, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.entries == nil {
+		c.entries = map[cloudTokenKey]cloudToken{}
+	}
+	var oldest cloudTokenKey
+	var oldestStored time.Time
+	for k, entry := range c.entries {
+		switch {
+		case now.Sub(entry.stored) > cloudTokenTTL:
+			delete(c.entries, k)
+		case oldestStored.IsZero() || entry.stored.Before(oldestStored):
+			oldest, oldestStored = k, entry.stored
+		}
+	}
+	if len(c.entries) >= maxCloudTokens {
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = cloudToken{token: token, stored: now}
+}
+
+func (c *cloudTokenCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// searchTasksCloud — эмуляция постраничного поиска по offset поверх токенов /search/jql.
+// Jira Cloud не возвращает total, поэтому Total = offset + len(Issues), плюс 1, если есть следующая страница:
+// этого достаточно, чтобы цикл вида `offset >= Total` дошёл до конца выборки.
+//
+// Токен следующей страницы запоминается по (query, fields, pageSize, offset), поэтому цикл `offset += pageSize`
+// делает по запросу на страницу. Токены хранятся не дольше cloudTokenTTL и не больше maxCloudTokens.
+// Произвольный offset без запомненного токена обходит выборку с начала — для выгрузки всех задач используйте SearchAllTasks
+func (j *jira) searchTasksCloud(ctx context.Context, query string, pageSize, offset int, fields ...string) (SearchResponse, error) {
+	resp := SearchResponse{StartAt: offset, MaxResults: pageSize}
+	key := cloudTokenKey{query: query, fields: strings.Join(fields, ","), pageSize: pageSize}
+	seen := 0
+	var pageToken string
+	if offset > 0 {
+		key.offset = offset
+		if token, ok := j.cloudTokens.take(key); ok {
+			pageToken, seen = token, offset
+		}
+	}
+	hasMore := false
+	req := SearchRequest{Jql: query, MaxResults: pageSize, Fields: fields}
+	err := searchCloudPagesFrom(ctx, j, req, pageToken, func(issues []IssueJira, nextPageToken string) error {
+		for _, issue := range issues {
+			switch {
+			case seen < offset:
+			case len(resp.Issues) < pageSize:
+				resp.Issues = append(resp.Issues, issue)
+			default:
+				hasMore = true
+				return errStopCloudSearch
+			}
+			seen++
+		}
+		if nextPageToken == "" {
+			return nil
+		}
+		key.offset = seen
+		j.cloudTokens.store(key, nextPageToken)
+		if len(resp.Issues) == pageSize {
+			hasMore = true
+			return errStopCloudSearch
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopCloudSearch) {
+		return SearchResponse{}, err
+	}
+	resp.Total = offset + len(resp.Issues)
+	if hasMore {
+		resp.Total++
+	}
+	return resp, nil
+}
+
+// marshal — сериализация тела запроса. В Jira Cloud строковые описание и тела комментариев заменяются на ADF
+func (j *jira) marshal(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil || !j.Cloud {
+		return b, err
+	}
+	doc, err := decodeAny(b)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(textToAdfFields(doc, ""))
+}
+
+// unmarshal — разбор ответа. В Jira Cloud документы ADF в описании и комментариях превращаются в текст
+func (j *jira) unmarshal(data []byte, v any) error {
+	if !j.Cloud {
+		return json.Unmarshal(data, v)
+	}
+	doc, err := decodeAny(data)
+	if err != nil {
+		return err
+	}
+	converted, err := json.Marshal(adfToTextFields(doc))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(converted, v)
+}
+
+// decodeAny — разбор произвольного JSON без потери точности чисел
+func decodeAny(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// textToAdfFields — заменяет строковые fields.description и body (комментарии) на документы ADF
+func textToAdfFields(node any, parentKey string) any {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			s, isString := value.(string)
+			if isString && (key == "body" || (key == "description" && parentKey == "fields")) {
+				v[key] = TextToAdf(s)
+				continue
+			}
+			v[key] = textToAdfFields(value, key)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = textToAdfFields(value, parentKey)
+		}
+	}
+	return node
+}
+
+// adfToTextFields — заменяет любые документы ADF в полях description и body на текст
+func adfToTextFields(node any) any {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if doc, ok := value.(map[string]any); ok && (key == "body" || key == "description") && doc["type"] == "doc" {
+				v[key] = AdfToText(doc)
+				continue
+			}
+			v[key] = adfToTextFields(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = adfToTextFields(value)
+		}
+	}
+	return node
+}
+
+// TextToAdf — документ ADF из простого текста: каждая строка — отдельный абзац
+func TextToAdf(text string) map[string]any {
+	var paragraphs []any
+	for _, line := range strings.Split(text, "\n") {
+		paragraph := map[string]any{"type": "paragraph"}
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			paragraph["content"] = []any{map[string]any{"type": "text", "text": line}}
+		}
+		paragraphs = append(paragraphs, paragraph)
+	}
+	return map[string]any{"type": "doc", "version": 1, "content": paragraphs}
+}
+
+// AdfToText — текстовое представление документа ADF: блоки разделяются переводом строки,
+// упоминания и эмодзи заменяются их текстом
+func AdfToText(doc map[string]any) string {
+	var b strings.Builder
+	writeAdfNode(&b, doc)
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func writeAdfNode(b *strings.Builder, node map[string]any) {
+	nodeType, _ := node["type"].(string)
+	attrs, _ := node["attrs"].(map[string]any)
+	switch nodeType {
+	case "text":
+		text, _ := node["text"].(string)
+		b.WriteString(text)
+		return
+	case "hardBreak":
+		b.WriteString("\n")
+		return
+	case "mention", "emoji", "status", "date":
+		if text, ok := attrs["text"].(string); ok {
+			b.WriteString(text)
+		}
+		return
+	case "inlineCard", "blockCard":
+		if url, ok := attrs["url"].(string); ok {
+			b.WriteString(url)
+		}
+		return
+	case "listItem":
+		b.WriteString("* ")
+	}
+	content, _ := node["content"].([]any)
+	for _, child := range content {
+		if childNode, ok := child.(map[string]any); ok {
+			writeAdfNode(b, childNode)
+		}
+	}
+	switch nodeType {
+	case "paragraph", "heading", "codeBlock", "rule":
+		b.WriteString("\n")
+	}
+}
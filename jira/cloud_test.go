@@ -0,0 +1,146 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdfConversion(t *testing.T) {
+	text := "Первая строка\n\nТретья строка"
+	require.Equal(t, text, AdfToText(TextToAdf(text)))
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{"type": "doc", "version": 1, "content": [
+		{"type": "paragraph", "content": [{"type": "text", "text": "Привет, "}, {"type": "mention", "attrs": {"text": "@Test User"}}]},
+		{"type": "bulletList", "content": [
+			{"type": "listItem", "content": [{"type": "paragraph", "content": [{"type": "text", "text": "пункт"}]}]}
+		]}
+	]}`), &doc))
+	require.Equal(t, "Привет, @Test User\n* пункт", AdfToText(doc))
+}
+
+func TestCloudMode(t *testing.T) {
+	const total = 250
+	var searches int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/3/search/jql", func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "bot@example.com", user)
+
+		var req cloudSearchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, []string{"*navigable"}, req.Fields)
+		searches++
+		start, _ := strconv.Atoi(req.NextPageToken)
+		end := min(start+req.MaxResults, total)
+		resp := map[string]any{"isLast": end == total}
+		if end < total {
+			resp["nextPageToken"] = strconv.Itoa(end)
+		}
+		var issues []any
+		for i := start; i < end; i++ {
+			issues = append(issues, map[string]any{"key": "KEY-" + strconv.Itoa(i+1), "fields": map[string]any{
+				"description": TextToAdf("описание " + strconv.Itoa(i+1)),
+				"assignee":    map[string]any{"accountId": "5b10ac8d82e05b22cc7d4ef5"},
+			}})
+		}
+		resp["issues"] = issues
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	})
+	var commentBody map[string]any
+	mux.HandleFunc("/rest/api/3/issue/KEY-1/comment", func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var req struct {
+			Body map[string]any `json:"body"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &req))
+		commentBody = req.Body
+		w.WriteHeader(http.StatusCreated)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	j := NewJiraCloud(srv.URL, "bot@example.com", "token")
+	ctx := context.Background()
+
+	all, err := j.SearchAllTasks(ctx, "project = TEST")
+	require.NoError(t, err)
+	require.Len(t, all, total)
+	require.Equal(t, "описание 1", all[0].Fields.Description)
+	require.Equal(t, "5b10ac8d82e05b22cc7d4ef5", all[0].Fields.Assignee.Identity())
+
+	page, err := j.SearchTasks(ctx, "project = TEST", 50, 180)
+	require.NoError(t, err)
+	require.Len(t, page.Issues, 50)
+	require.Equal(t, "KEY-181", page.Issues[0].Key)
+	require.Greater(t, page.Total, 230)
+
+	// Токен страницы 200 запомнен предыдущим вызовом
+	searches = 0
+	last, err := j.SearchTasks(ctx, "project = TEST", 50, 200)
+	require.NoError(t, err)
+	require.Len(t, last.Issues, 50)
+	require.Equal(t, total, last.Total)
+	require.Equal(t, 1, searches)
+
+	// Цикл по offset делает по запросу на страницу
+	searches = 0
+	var keys []string
+	for offset := 0; ; {
+		resp, err := j.SearchTasks(ctx, "project = TEST", 50, offset)
+		require.NoError(t, err)
+		for _, issue := range resp.Issues {
+			keys = append(keys, issue.Key)
+		}
+		offset += len(resp.Issues)
+		if len(resp.Issues) == 0 || offset >= resp.Total {
+			break
+		}
+	}
+	require.Len(t, keys, total)
+	require.Equal(t, "KEY-250", keys[total-1])
+	require.Equal(t, total/50, searches)
+
+	require.NoError(t, j.CommentIssue(ctx, "KEY-1", "комментарий"))
+	require.Equal(t, "doc", commentBody["type"])
+	require.Equal(t, "комментарий", AdfToText(commentBody))
+}
+
+func TestCloudTokenCache(t *testing.T) {
+	var cache cloudTokenCache
+	key := func(offset int) cloudTokenKey {
+		return cloudTokenKey{query: "project = TEST", pageSize: 50, offset: offset}
+	}
+
+	// Брошенные циклы не раздувают кеш: самые старые токены вытесняются
+	for offset := 1; offset <= maxCloudTokens+10; offset++ {
+		cache.store(key(offset), fmt.Sprint("token-", offset))
+	}
+	require.Equal(t, maxCloudTokens, cache.len())
+	_, ok := cache.take(key(1))
+	require.False(t, ok)
+	token, ok := cache.take(key(maxCloudTokens + 10))
+	require.True(t, ok)
+	require.Equal(t, fmt.Sprint("token-", maxCloudTokens+10), token)
+
+	// Устаревшие токены не отдаются и удаляются при следующей записи
+	cache.entries[key(11)] = cloudToken{token: "old", stored: time.Now().Add(-2 * cloudTokenTTL)}
+	_, ok = cache.take(key(11))
+	require.False(t, ok)
+	cache.entries[key(12)] = cloudToken{token: "old", stored: time.Now().Add(-2 * cloudTokenTTL)}
+	cache.store(key(0), "token")
+	_, ok = cache.entries[key(12)]
+	require.False(t, ok)
+}
@@ -0,0 +1,359 @@
+package jira
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Типы значений из schema.type/schema.items метаданных создания задачи
+const (
+	metaTypeArray     = "array"
+	metaTypeOption    = "option"
+	metaTypeNumber    = "number"
+	metaTypeUser      = "user"
+	metaTypeProject   = "project"
+	metaTypeIssueType = "issuetype"
+)
+
+// Максимальное количество подсказок для неверного значения
+const maxValueSuggestions = 3
+
+// FieldProblem — проблема с одним полем при предварительной проверке создания задачи
+type FieldProblem struct {
+	FieldID     string
+	Name        string
+	Message     string
+	Suggestions []string
+}
+
+func (p FieldProblem) String() string {
+	s := fmt.Sprintf("%s (%s): %s", p.Name, p.FieldID, p.Message)
+	if len(p.Suggestions) > 0 {
+		s += fmt.Sprintf(", maybe: %s", strings.Join(p.Suggestions, ", "))
+	}
+	return s
+}
+
+// ValidationError — ошибка предварительной проверки, содержит все найденные проблемы
+type ValidationError struct {
+	Problems []FieldProblem
+}
+
+func (e ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Problems))
+	for _, p := range e.Problems {
+		lines = append(lines, p.String())
+	}
+	return "create issue validation failed:\n" + strings.Join(lines, "\n")
+}
+
+// FindField — возвращает описание поля по его идентификатору
+func (i *IssueTypeMeta) FindField(fieldId string) (MetaField, bool) {
+	for _, field := range i.Values {
+		if field.FieldID == fieldId {
+			return field, true
+		}
+	}
+	return MetaField{}, false
+}
+
+// Validate — проверяет тело создания задачи: обязательные поля и значения из списков допустимых.
+// project и issuetype не проверяются, т.к. метаданные уже получены для конкретных проекта и типа
+func (i *IssueTypeMeta) Validate(fields map[string]any) []FieldProblem {
+	var problems []FieldProblem
+	for _, field := range i.Values {
+		if field.FieldID == Issue.Fields.Project || field.FieldID == Issue.Fields.IssueType {
+			continue
+		}
+		value, ok := fields[field.FieldID]
+		if !ok || isEmptyFieldValue(value) {
+			if field.Required && !field.HasDefaultValue {
+				problems = append(problems, FieldProblem{FieldID: field.FieldID, Name: field.Name, Message: "required field is empty"})
+			}
+			continue
+		}
+		if len(field.AllowedValues) == 0 {
+			continue
+		}
+		for _, v := range fieldValueItems(value) {
+			if _, found := field.findAllowedValue(v); found {
+				continue
+			}
+			problems = append(problems, FieldProblem{
+				FieldID:     field.FieldID,
+				Name:        field.Name,
+				Message:     fmt.Sprintf("value %q is not allowed", v),
+				Suggestions: field.suggestAllowedValues(v),
+			})
+		}
+	}
+	for fieldId := range fields {
+		if _, ok := i.FindField(fieldId); !ok && fieldId != Issue.Fields.Project && fieldId != Issue.Fields.IssueType {
+			problems = append(problems, FieldProblem{FieldID: fieldId, Name: fieldId, Message: "field is not on the create screen"})
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b FieldProblem) int { return strings.Compare(a.FieldID, b.FieldID) })
+	return problems
+}
+
+// BuildFields — превращает человекочитаемые значения (название заказчика, имя пользователя,
+// название компонента) в JSON-формы, которые ожидает Jira при создании задачи.
+// Ключи values — идентификаторы полей, как в Issue.Fields
+func (i *IssueTypeMeta) BuildFields(values map[string]any) (map[string]any, error) {
+	res := make(map[string]any, len(values))
+	var problems []FieldProblem
+	for fieldId, value := range values {
+		field, ok := i.FindField(fieldId)
+		if !ok {
+			// Поля вне экрана создания отдаём как есть, Validate про них сообщит
+			res[fieldId] = value
+			continue
+		}
+		built, problem := field.buildValue(value)
+		if problem != nil {
+			problems = append(problems, *problem)
+			continue
+		}
+		res[fieldId] = built
+	}
+	if len(problems) > 0 {
+		slices.SortStableFunc(problems, func(a, b FieldProblem) int { return strings.Compare(a.FieldID, b.FieldID) })
+		return nil, ValidationError{Problems: problems}
+	}
+	return res, nil
+}
+
+func (f MetaField) buildValue(value any) (any, *FieldProblem) {
+	if f.Schema.Type == metaTypeArray {
+		items := fieldValueItems(value)
+		res := make([]any, 0, len(items))
+		for _, item := range items {
+			built, problem := f.buildSingleValue(f.Schema.Items, item)
+			if problem != nil {
+				return nil, problem
+			}
+			res = append(res, built)
+		}
+		return res, nil
+	}
+	if s, ok := value.(string); ok {
+		return f.buildSingleValue(f.Schema.Type, s)
+	}
+	// Уже собранные значения (map, числа, структуры) не трогаем
+	return value, nil
+}
+
+func (f MetaField) buildSingleValue(schemaType, value string) (any, *FieldProblem) {
+	if len(f.AllowedValues) > 0 {
+		allowed, found := f.findAllowedValue(value)
+		if !found {
+			return nil, &FieldProblem{
+				FieldID:     f.FieldID,
+				Name:        f.Name,
+				Message:     fmt.Sprintf("value %q is not allowed", value),
+				Suggestions: f.suggestAllowedValues(value),
+			}
+		}
+		return map[string]any{"id": allowed.ID}, nil
+	}
+	switch schemaType {
+	case metaTypeUser:
+		if f.cloud {
+			return map[string]any{"accountId": value}, nil
+		}
+		return map[string]any{"name": value}, nil
+	case metaTypeProject:
+		return map[string]any{"key": value}, nil
+	case metaTypeNumber:
+		n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, &FieldProblem{FieldID: f.FieldID, Name: f.Name, Message: fmt.Sprintf("value %q is not a number", value)}
+		}
+		return n, nil
+	case metaTypeOption:
+		return map[string]any{"value": value}, nil
+	case metaTypeIssueType:
+		return map[string]any{"name": value}, nil
+	}
+	// string, date, datetime, labels и прочие простые типы Jira принимает строкой
+	return value, nil
+}
+
+func (f MetaField) findAllowedValue(value string) (IssueField, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, allowed := range f.AllowedValues {
+		for _, candidate := range []string{allowed.Value, allowed.Name, allowed.Key, allowed.ID} {
+			if candidate != "" && strings.ToLower(candidate) == value {
+				return allowed, true
+			}
+		}
+	}
+	return IssueField{}, false
+}
+
+// suggestAllowedValues — ближайшие по расстоянию Левенштейна допустимые значения
+func (f MetaField) suggestAllowedValues(value string) []string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	type candidate struct {
+		label    string
+		distance int
+	}
+	var candidates []candidate
+	for _, allowed := range f.AllowedValues {
+		label := cmp.Or(allowed.Value, allowed.Name)
+		if label == "" || allowed.Disabled {
+			continue
+		}
+		lower := strings.ToLower(label)
+		distance := levenshtein(value, lower)
+		if strings.Contains(lower, value) || strings.Contains(value, lower) {
+			distance = 0
+		}
+		if distance > max(2, len([]rune(value))/3) {
+			continue
+		}
+		candidates = append(candidates, candidate{label: label, distance: distance})
+	}
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return a.distance - b.distance })
+	var res []string
+	for _, c := range candidates[:min(len(candidates), maxValueSuggestions)] {
+		res = append(res, c.label)
+	}
+	return res
+}
+
+// ValidateCreateIssue — предварительная проверка тела создания задачи по метаданным проекта и типа задачи.
+// Тип задачи, как и в CreateIssue, можно указать по id или по названию
+func (j *jira) ValidateCreateIssue(ctx context.Context, req FieldsIssue) error {
+	if req.Project.Key == "" || req.IssueType.ID == "" && strings.TrimSpace(req.IssueType.Name) == "" {
+		return fmt.Errorf("project key and issuetype id or name are required for validation")
+	}
+	fields, err := fieldsIssueToMap(req)
+	if err != nil {
+		return err
+	}
+	issueTypeId := req.IssueType.ID
+	if issueTypeId == "" {
+		if issueTypeId, err = j.issueTypeIdByName(ctx, req.Project.Key, req.IssueType.Name); err != nil {
+			return err
+		}
+	}
+	meta, err := j.GetIssueTypeMeta(ctx, req.Project.Key, issueTypeId)
+	if err != nil {
+		return fmt.Errorf("failed to get create meta: %w", err)
+	}
+	if problems := meta.Validate(fields); len(problems) > 0 {
+		return ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// CreateIssueFromValues — создать задачу из человекочитаемых значений полей.
+// Значения приводятся к формату Jira по метаданным и проверяются до отправки запроса
+func (j *jira) CreateIssueFromValues(ctx context.Context, projectKey, issueTypeId string, values map[string]any) (CreatedIssueResponse, error) {
+	meta, err := j.GetIssueTypeMeta(ctx, projectKey, issueTypeId)
+	if err != nil {
+		return CreatedIssueResponse{}, fmt.Errorf("failed to get create meta: %w", err)
+	}
+	fields, err := meta.BuildFields(values)
+	if err != nil {
+		return CreatedIssueResponse{}, err
+	}
+	fields[Issue.Fields.Project] = map[string]any{"key": projectKey}
+	fields[Issue.Fields.IssueType] = map[string]any{"id": issueTypeId}
+	if problems := meta.Validate(fields); len(problems) > 0 {
+		return CreatedIssueResponse{}, ValidationError{Problems: problems}
+	}
+	return j.CreateIssueFromMap(ctx, fields)
+}
+
+func fieldsIssueToMap(req FieldsIssue) (map[string]any, error) {
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// fieldValueItems — строковые представления значения поля: для option это value/name/id, для массивов — каждый элемент
+func fieldValueItems(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []any:
+		var res []string
+		for _, item := range v {
+			res = append(res, fieldValueItems(item)...)
+		}
+		return res
+	case map[string]any:
+		for _, key := range []string{"id", "value", "name", "key"} {
+			if s, ok := v[key].(string); ok && s != "" {
+				return []string{s}
+			}
+		}
+	case []map[string]any:
+		var res []string
+		for _, item := range v {
+			res = append(res, fieldValueItems(item)...)
+		}
+		return res
+	case IssueField:
+		return []string{cmp.Or(v.ID, v.Value, v.Name, v.Key)}
+	case []IssueField:
+		var res []string
+		for _, item := range v {
+			res = append(res, fieldValueItems(item)...)
+		}
+		return res
+	}
+	return nil
+}
+
+func isEmptyFieldValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,120 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testCreateMeta() *IssueTypeMeta {
+	return &IssueTypeMeta{Values: []MetaField{
+		{FieldID: Issue.Fields.Summary, Name: "Тема", Required: true, Schema: MetaSchema{Type: "string"}},
+		{FieldID: Issue.Fields.Project, Name: "Проект", Required: true, Schema: MetaSchema{Type: metaTypeProject}},
+		{FieldID: Issue.Fields.Customer, Name: "Заказчик", Required: true, Schema: MetaSchema{Type: metaTypeOption},
+			AllowedValues: []IssueField{{ID: "12669", Value: "Сбербанк"}, {ID: "12670", Value: "Сбер Лизинг"}, {ID: "12671", Value: "Альфа-Банк"}}},
+		{FieldID: Issue.Fields.Components, Name: "Компоненты", Schema: MetaSchema{Type: metaTypeArray, Items: "component"},
+			AllowedValues: []IssueField{{ID: "48150", Name: "Jira"}, {ID: "48151", Name: "Мониторинг"}}},
+		{FieldID: Issue.Fields.Assignee, Name: "Исполнитель", Schema: MetaSchema{Type: metaTypeUser}},
+		{FieldID: Issue.Fields.StoryPoints, Name: "Story Points", Schema: MetaSchema{Type: metaTypeNumber}},
+		{FieldID: Issue.Fields.Priority, Name: "Приоритет", Required: true, HasDefaultValue: true, Schema: MetaSchema{Type: "priority"},
+			AllowedValues: []IssueField{{ID: "3", Name: "Major"}, {ID: "4", Name: "Minor"}}},
+	}}
+}
+
+func TestIssueTypeMetaValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields map[string]any
+		want   []FieldProblem
+	}{
+		{name: "01. Все обязательные поля заполнены", fields: map[string]any{
+			Issue.Fields.Summary:  "Тест",
+			Issue.Fields.Customer: map[string]any{"id": "12669"},
+		}},
+		{name: "02. Пропущено обязательное поле без значения по умолчанию", fields: map[string]any{
+			Issue.Fields.Summary: "Тест",
+		}, want: []FieldProblem{
+			{FieldID: Issue.Fields.Customer, Name: "Заказчик", Message: "required field is empty"},
+		}},
+		{name: "03. Недопустимое значение с подсказками", fields: map[string]any{
+			Issue.Fields.Summary:  "Тест",
+			Issue.Fields.Customer: map[string]any{"value": "Сбер"},
+		}, want: []FieldProblem{
+			{FieldID: Issue.Fields.Customer, Name: "Заказчик", Message: `value "Сбер" is not allowed`, Suggestions: []string{"Сбербанк", "Сбер Лизинг"}},
+		}},
+		{name: "04. Поле не с экрана создания", fields: map[string]any{
+			Issue.Fields.Summary:     "Тест",
+			Issue.Fields.Customer:    map[string]any{"id": "12669"},
+			Issue.Fields.WeightedJob: 5,
+		}, want: []FieldProblem{
+			{FieldID: Issue.Fields.WeightedJob, Name: Issue.Fields.WeightedJob, Message: "field is not on the create screen"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, testCreateMeta().Validate(tt.fields))
+		})
+	}
+}
+
+func TestIssueTypeMetaBuildFields(t *testing.T) {
+	got, err := testCreateMeta().BuildFields(map[string]any{
+		Issue.Fields.Summary:     "Тест",
+		Issue.Fields.Customer:    "альфа-банк",
+		Issue.Fields.Components:  []string{"Jira", "мониторинг"},
+		Issue.Fields.Assignee:    "testuser",
+		Issue.Fields.StoryPoints: "8",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		Issue.Fields.Summary:     "Тест",
+		Issue.Fields.Customer:    map[string]any{"id": "12671"},
+		Issue.Fields.Components:  []any{map[string]any{"id": "48150"}, map[string]any{"id": "48151"}},
+		Issue.Fields.Assignee:    map[string]any{"name": "testuser"},
+		Issue.Fields.StoryPoints: 8.0,
+	}, got)
+
+	_, err = testCreateMeta().BuildFields(map[string]any{Issue.Fields.Customer: "Альфабанк"})
+	var validationErr ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Problems, 1)
+	require.Equal(t, []string{"Альфа-Банк"}, validationErr.Problems[0].Suggestions)
+}
+
+func TestValidateCreateIssue(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /issue/createmeta/NEW/issuetypes", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"values": [{"id": "10000", "name": "Epic"}, {"id": "10001", "name": "Задача"}]}`))
+	})
+	mux.HandleFunc("GET /issue/createmeta/NEW/issuetypes/10001", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(testCreateMeta()))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	j := &jira{BaseUrl: srv.URL, Token: "token"}
+	ctx := context.Background()
+
+	t.Run("01. Тип задачи по названию", func(t *testing.T) {
+		err := j.ValidateCreateIssue(ctx, FieldsIssue{Summary: "Тест", Project: JiraProject{IssueField: IssueField{Key: "NEW"}}, IssueType: IssueField{Name: "задача"}})
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, Issue.Fields.Customer, validationErr.Problems[0].FieldID)
+	})
+
+	t.Run("02. Тип задачи по id", func(t *testing.T) {
+		err := j.ValidateCreateIssue(ctx, FieldsIssue{Summary: "Тест", Project: JiraProject{IssueField: IssueField{Key: "NEW"}}, IssueType: IssueField{ID: "10001"}})
+		var validationErr ValidationError
+		require.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("03. Нет такого типа задачи", func(t *testing.T) {
+		err := j.ValidateCreateIssue(ctx, FieldsIssue{Summary: "Тест", Project: JiraProject{IssueField: IssueField{Key: "NEW"}}, IssueType: IssueField{Name: "Баг"}})
+		require.ErrorContains(t, err, `issuetype "Баг" not found in project NEW`)
+	})
+}
@@ -0,0 +1,324 @@
+package jira
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	digestDateFormat = "02.01.2006"
+	digestTopSize    = 5
+	digestNoAssignee = "Не назначен"
+)
+
+// DigestCount — количество задач (событий) для одного значения: статуса, исполнителя, автора
+type DigestCount struct {
+	Name  string
+	Count int
+}
+
+// OverdueIssue — открытая задача с истёкшим сроком исполнения или SLA
+type OverdueIssue struct {
+	Key      string
+	Summary  string
+	Assignee string
+	Deadline time.Time
+	Sla      bool // просрочен SLA, а не срок исполнения
+}
+
+// ProjectDigest — сводка о состоянии проекта за период [From, To)
+type ProjectDigest struct {
+	ProjectKey     string
+	From           time.Time
+	To             time.Time
+	Created        int           // создано за период
+	Resolved       int           // решено за период
+	Open           int           // открыто сейчас
+	OpenByStatus   []DigestCount // открытые задачи по статусам, по убыванию
+	OpenByAssignee []DigestCount // открытые задачи по исполнителям, по убыванию
+	Overdue        []OverdueIssue
+	CycleTime      time.Duration // среднее время от начала работы до решения по задачам, решённым за период
+	Reopened       int           // переоткрытий за период
+	TopReopeners   []DigestCount // кто чаще всего переоткрывал задачи
+}
+
+// BuildProjectDigest — собирает сводку по проекту за период [from, to). Просрочка считается на момент to.
+// Время цикла — от первой смены статуса (или создания, если её не было) до последней установки решения.
+// Границы периода передаются в JQL относительно текущего момента (см. digestJqlTime), поэтому окно
+// не зависит ни от часового пояса, где запущена сводка, ни от часового пояса профиля пользователя Jira
+func BuildProjectDigest(ctx context.Context, api ApiJira, projectKey string, from, to time.Time) (ProjectDigest, error) {
+	if strings.TrimSpace(projectKey) == "" {
+		return ProjectDigest{}, fmt.Errorf("projectKey is empty")
+	}
+	if !from.Before(to) {
+		return ProjectDigest{}, fmt.Errorf("invalid period: %s — %s", from, to)
+	}
+	digest := ProjectDigest{ProjectKey: projectKey, From: from, To: to}
+	now := time.Now()
+
+	created, err := api.SearchAllTasks(ctx,
+		fmt.Sprintf("project = %s AND %s", projectKey, digestPeriodJql("created", from, to, now)), Issue.Fields.Summary)
+	if err != nil {
+		return digest, fmt.Errorf("failed to search created issues: %w", err)
+	}
+	digest.Created = len(created)
+
+	resolved, err := api.SearchAllTasksExpand(ctx,
+		fmt.Sprintf("project = %s AND %s", projectKey, digestPeriodJql("resolved", from, to, now)), digestExpand, Issue.Fields.Created)
+	if err != nil {
+		return digest, fmt.Errorf("failed to search resolved issues: %w", err)
+	}
+	digest.Resolved = len(resolved)
+
+	var cycleTotal time.Duration
+	var cycleCount int
+	for _, issue := range resolved {
+		histories, err := issueHistories(ctx, api, issue)
+		if err != nil {
+			return digest, err
+		}
+		if cycle, ok := issueCycleTime(issue.Fields.Created.Time, histories); ok {
+			cycleTotal += cycle
+			cycleCount++
+		}
+	}
+	if cycleCount > 0 {
+		digest.CycleTime = cycleTotal / time.Duration(cycleCount)
+	}
+
+	open, err := api.SearchAllTasks(ctx, fmt.Sprintf("project = %s AND resolution = Unresolved ORDER BY key", projectKey),
+		Issue.Fields.Summary, Issue.Fields.Status, Issue.Fields.Assignee, Issue.Fields.DueDate, Issue.Fields.SLAExpire)
+	if err != nil {
+		return digest, fmt.Errorf("failed to search open issues: %w", err)
+	}
+	digest.Open = len(open)
+	byStatus := map[string]int{}
+	byAssignee := map[string]int{}
+	for _, issue := range open {
+		byStatus[issue.Fields.Status.Name]++
+		assignee := digestUserName(issue.Fields.Assignee)
+		byAssignee[assignee]++
+		if overdue, ok := overdueIssue(issue, assignee, to); ok {
+			digest.Overdue = append(digest.Overdue, overdue)
+		}
+	}
+	digest.OpenByStatus = sortDigestCounts(byStatus, 0)
+	digest.OpenByAssignee = sortDigestCounts(byAssignee, 0)
+	slices.SortStableFunc(digest.Overdue, func(a, b OverdueIssue) int { return a.Deadline.Compare(b.Deadline) })
+
+	reopened, err := api.SearchAllTasksExpand(ctx, fmt.Sprintf(`project = %s AND status CHANGED TO %s DURING ("%s", "%s")`,
+		projectKey, Issue.Status.Reopened, digestJqlTime(from, now), digestJqlTime(to, now)), digestExpand, Issue.Fields.Summary)
+	if err != nil {
+		return digest, fmt.Errorf("failed to search reopened issues: %w", err)
+	}
+	reopeners := map[string]int{}
+	for _, issue := range reopened {
+		histories, err := issueHistories(ctx, api, issue)
+		if err != nil {
+			return digest, err
+		}
+		for _, history := range histories {
+			if history.Created.Before(from) || !history.Created.Before(to) {
+				continue
+			}
+			if history.FindItemByField(Changelog.SingleItem.Field.Status).To == Issue.Status.Reopened {
+				digest.Reopened++
+				reopeners[digestUserName(history.Author)]++
+			}
+		}
+	}
+	digest.TopReopeners = sortDigestCounts(reopeners, digestTopSize)
+	return digest, nil
+}
+
+// digestExpand — история изменений приходит вместе с результатами поиска
+var digestExpand = []string{"changelog"}
+
+// digestPeriodJql — условие JQL на попадание даты в период [from, to)
+func digestPeriodJql(field string, from, to, now time.Time) string {
+	return fmt.Sprintf(`%s >= "%s" AND %s < "%s"`, field, digestJqlTime(from, now), field, digestJqlTime(to, now))
+}
+
+// digestJqlTime — момент t в JQL как смещение от now в минутах, например "-10080m".
+// Абсолютную дату Jira понимает в часовом поясе профиля пользователя, а смещение от текущего момента — нет
+func digestJqlTime(t, now time.Time) string {
+	return fmt.Sprintf("%dm", int(t.Sub(now).Round(time.Minute)/time.Minute))
+}
+
+// issueHistories — история изменений из результата поиска. Если Jira вернула её не полностью, история запрашивается отдельно
+func issueHistories(ctx context.Context, api ApiJira, issue IssueJira) ([]ChangeLog, error) {
+	if issue.Changelog.Total <= len(issue.Changelog.Histories) {
+		return issue.Changelog.Histories, nil
+	}
+	histories, err := api.GetIssueChangelog(ctx, issue.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changelog of %s: %w", issue.Key, err)
+	}
+	return histories, nil
+}
+
+// issueCycleTime — время от начала работы над задачей до её решения по истории изменений
+func issueCycleTime(created time.Time, histories []ChangeLog) (time.Duration, bool) {
+	var start, end time.Time
+	for _, history := range histories {
+		if start.IsZero() && history.FindItemByField(Changelog.SingleItem.Field.Status).Field != "" {
+			start = history.Created.Time
+		}
+		if item := history.FindItemByField(Changelog.SingleItem.Field.Resolution); item.Field != "" && item.To != "" {
+			end = history.Created.Time
+		}
+	}
+	if start.IsZero() || start.Equal(end) {
+		start = created
+	}
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0, false
+	}
+	return end.Sub(start), true
+}
+
+// overdueIssue — просрочена ли открытая задача на момент now. Срок исполнения действует до конца дня
+func overdueIssue(issue IssueJira, assignee string, now time.Time) (OverdueIssue, bool) {
+	res := OverdueIssue{Key: issue.Key, Summary: issue.Fields.Summary, Assignee: assignee}
+	if sla := issue.Fields.SlaExpire.Time; !sla.IsZero() && sla.Before(now) {
+		res.Deadline, res.Sla = sla, true
+		return res, true
+	}
+	if issue.Fields.DueDate == "" {
+		return res, false
+	}
+	due, err := time.ParseInLocation(time.DateOnly, issue.Fields.DueDate, now.Location())
+	if err != nil || now.Before(due.AddDate(0, 0, 1)) {
+		return res, false
+	}
+	res.Deadline = due
+	return res, true
+}
+
+func digestUserName(user JiraUser) string {
+	return cmp.Or(user.DisplayName, user.Identity(), digestNoAssignee)
+}
+
+// sortDigestCounts — счётчики по убыванию, при равенстве по названию. top > 0 ограничивает количество
+func sortDigestCounts(counts map[string]int, top int) []DigestCount {
+	res := make([]DigestCount, 0, len(counts))
+	for name, count := range counts {
+		res = append(res, DigestCount{Name: name, Count: count})
+	}
+	slices.SortFunc(res, func(a, b DigestCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), strings.Compare(a.Name, b.Name))
+	})
+	if top > 0 && len(res) > top {
+		res = res[:top]
+	}
+	return res
+}
+
+func (d ProjectDigest) title() string {
+	return fmt.Sprintf("Сводка по проекту %s за %s — %s",
+		d.ProjectKey, d.From.Format(digestDateFormat), d.To.Add(-time.Nanosecond).Format(digestDateFormat))
+}
+
+// RenderConfluence — сводка в формате хранения Confluence (storage XHTML)
+func (d ProjectDigest) RenderConfluence() string {
+	var b strings.Builder
+	esc := html.EscapeString
+	fmt.Fprintf(&b, "<h2>%s</h2>\n", esc(d.title()))
+	b.WriteString("<table>\n<tbody>\n")
+	for _, row := range [][2]string{
+		{"Создано", fmt.Sprint(d.Created)},
+		{"Решено", fmt.Sprint(d.Resolved)},
+		{"Открыто", fmt.Sprint(d.Open)},
+		{"Просрочено", fmt.Sprint(len(d.Overdue))},
+		{"Среднее время цикла", formatDigestDuration(d.CycleTime)},
+		{"Переоткрытий", fmt.Sprint(d.Reopened)},
+	} {
+		fmt.Fprintf(&b, "<tr><th>%s</th><td>%s</td></tr>\n", esc(row[0]), esc(row[1]))
+	}
+	b.WriteString("</tbody>\n</table>\n")
+
+	writeCounts := func(header, column string, counts []DigestCount) {
+		if len(counts) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "<h3>%s</h3>\n<table>\n<tbody>\n<tr><th>%s</th><th>Задач</th></tr>\n", esc(header), esc(column))
+		for _, c := range counts {
+			fmt.Fprintf(&b, "<tr><td>%s</td><td>%d</td></tr>\n", esc(c.Name), c.Count)
+		}
+		b.WriteString("</tbody>\n</table>\n")
+	}
+	writeCounts("Открытые задачи по статусам", "Статус", d.OpenByStatus)
+	writeCounts("Открытые задачи по исполнителям", "Исполнитель", d.OpenByAssignee)
+
+	if len(d.Overdue) > 0 {
+		b.WriteString("<h3>Просроченные задачи</h3>\n<table>\n<tbody>\n")
+		b.WriteString("<tr><th>Задача</th><th>Тема</th><th>Исполнитель</th><th>Срок</th></tr>\n")
+		for _, o := range d.Overdue {
+			fmt.Fprintf(&b, `<tr><td><ac:structured-macro ac:name="jira" ac:schema-version="1">`+
+				`<ac:parameter ac:name="key">%s</ac:parameter></ac:structured-macro></td>`+
+				"<td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				esc(o.Key), esc(o.Summary), esc(o.Assignee), esc(o.deadlineText()))
+		}
+		b.WriteString("</tbody>\n</table>\n")
+	}
+	writeCounts("Чаще всего переоткрывали", "Пользователь", d.TopReopeners)
+	return b.String()
+}
+
+// RenderTelegram — сводка в HTML-разметке Telegram
+func (d ProjectDigest) RenderTelegram() string {
+	var b strings.Builder
+	esc := html.EscapeString
+	fmt.Fprintf(&b, "<b>%s</b>\n\n", esc(d.title()))
+	fmt.Fprintf(&b, "Создано: <b>%d</b>, решено: <b>%d</b>\n", d.Created, d.Resolved)
+	fmt.Fprintf(&b, "Открыто: <b>%d</b>, просрочено: <b>%d</b>\n", d.Open, len(d.Overdue))
+	fmt.Fprintf(&b, "Среднее время цикла: <b>%s</b>\n", esc(formatDigestDuration(d.CycleTime)))
+	fmt.Fprintf(&b, "Переоткрытий: <b>%d</b>\n", d.Reopened)
+
+	writeCounts := func(header string, counts []DigestCount) {
+		if len(counts) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n<b>%s</b>\n", esc(header))
+		for _, c := range counts {
+			fmt.Fprintf(&b, "• %s — %d\n", esc(c.Name), c.Count)
+		}
+	}
+	writeCounts("По статусам", d.OpenByStatus)
+	writeCounts("По исполнителям", d.OpenByAssignee)
+	if len(d.Overdue) > 0 {
+		b.WriteString("\n<b>Просрочено</b>\n")
+		for _, o := range d.Overdue {
+			fmt.Fprintf(&b, "• <code>%s</code> %s (%s, %s)\n", esc(o.Key), esc(o.Summary), esc(o.Assignee), esc(o.deadlineText()))
+		}
+	}
+	writeCounts("Чаще всего переоткрывали", d.TopReopeners)
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (o OverdueIssue) deadlineText() string {
+	if o.Sla {
+		return "SLA " + o.Deadline.Format(exportTimeFormat)
+	}
+	return o.Deadline.Format(digestDateFormat)
+}
+
+// formatDigestDuration — длительность в днях и часах, например «3 д 4 ч»
+func formatDigestDuration(d time.Duration) string {
+	if d <= 0 {
+		return "—"
+	}
+	hours := int(d.Round(time.Hour) / time.Hour)
+	if hours < 24 {
+		return fmt.Sprintf("%d ч", max(hours, 1))
+	}
+	if hours%24 == 0 {
+		return fmt.Sprintf("%d д", hours/24)
+	}
+	return fmt.Sprintf("%d д %d ч", hours/24, hours%24)
+}
@@ -0,0 +1,144 @@
+package jira
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// digestJira — заглушка ApiJira: задачи выбираются по началу JQL, история изменений — по ключу
+type digestJira struct {
+	ApiJira
+	searches        map[string][]IssueJira
+	changelogs      map[string][]ChangeLog
+	queries         []string
+	changelogCalls  int
+	truncateHistory bool // отдавать в поиске только первую запись истории
+}
+
+func (d *digestJira) SearchAllTasks(ctx context.Context, query string, fields ...string) ([]IssueJira, error) {
+	return d.SearchAllTasksExpand(ctx, query, nil, fields...)
+}
+
+func (d *digestJira) SearchAllTasksExpand(_ context.Context, query string, expand []string, _ ...string) ([]IssueJira, error) {
+	d.queries = append(d.queries, query)
+	for prefix, issues := range d.searches {
+		if !strings.HasPrefix(query, prefix) {
+			continue
+		}
+		res := slices.Clone(issues)
+		for i := range res {
+			if histories := d.changelogs[res[i].Key]; slices.Contains(expand, "changelog") && len(histories) > 0 {
+				res[i].Changelog = IssueHistory{Total: len(histories), Histories: histories}
+				if d.truncateHistory {
+					res[i].Changelog.Histories = histories[:1]
+				}
+			}
+		}
+		return res, nil
+	}
+	return nil, nil
+}
+
+func (d *digestJira) GetIssueChangelog(_ context.Context, issueId string) ([]ChangeLog, error) {
+	d.changelogCalls++
+	return d.changelogs[issueId], nil
+}
+
+func TestBuildProjectDigest(t *testing.T) {
+	from := time.Date(2025, 1, 13, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, 7)
+	at := func(day, hour int) JiraTime {
+		return JiraTime{Time: time.Date(2025, 1, day, hour, 0, 0, 0, time.Local)}
+	}
+	statusChange := func(created JiraTime, statusId string) ChangeLog {
+		return ChangeLog{Created: created, Author: JiraUser{DisplayName: "Иван Петров"}, Items: []ChangelogItem{
+			{Field: Changelog.SingleItem.Field.Status, To: statusId},
+		}}
+	}
+	resolve := func(created JiraTime) ChangeLog {
+		change := statusChange(created, Issue.Status.Resolved)
+		change.Items = append(change.Items, ChangelogItem{Field: Changelog.SingleItem.Field.Resolution, To: "1", ToString: "Fixed"})
+		return change
+	}
+
+	api := &digestJira{
+		searches: map[string][]IssueJira{
+			"project = TEST AND created": {{Key: "TEST-5"}, {Key: "TEST-6"}, {Key: "TEST-7"}},
+			"project = TEST AND resolved": {
+				{Key: "TEST-1", Fields: FieldsIssue{Created: at(10, 10)}},
+				{Key: "TEST-2", Fields: FieldsIssue{Created: at(13, 9)}},
+			},
+			"project = TEST AND resolution = Unresolved": {
+				{Key: "TEST-3", Fields: FieldsIssue{Summary: "Срок <вчера>", DueDate: "2025-01-19",
+					Status: IssueField{Name: "В работе"}, Assignee: JiraUser{Name: "ivan", DisplayName: "Иван Петров"}}},
+				{Key: "TEST-4", Fields: FieldsIssue{Summary: "SLA", SlaExpire: at(15, 12), Status: IssueField{Name: "Новый"}}},
+				{Key: "TEST-6", Fields: FieldsIssue{Summary: "Срок сегодня", DueDate: "2025-01-20", Status: IssueField{Name: "Новый"}}},
+			},
+			"project = TEST AND status CHANGED TO": {{Key: "TEST-1"}},
+		},
+		changelogs: map[string][]ChangeLog{
+			// Работа началась 11-го, окончательно решена 16-го: 5 дней
+			"TEST-1": {statusChange(at(11, 10), Issue.Status.InProgress), resolve(at(12, 10)), statusChange(at(12, 18), Issue.Status.Reopened),
+				statusChange(at(15, 10), Issue.Status.Reopened), resolve(at(16, 10))},
+			// Решена сразу после создания: 6 часов
+			"TEST-2": {resolve(at(13, 15))},
+		},
+	}
+
+	digest, err := BuildProjectDigest(context.Background(), api, "TEST", from, to)
+	require.NoError(t, err)
+	require.Equal(t, 3, digest.Created)
+	require.Equal(t, 2, digest.Resolved)
+	require.Equal(t, 3, digest.Open)
+	require.Equal(t, []DigestCount{{Name: "Новый", Count: 2}, {Name: "В работе", Count: 1}}, digest.OpenByStatus)
+	require.Equal(t, []DigestCount{{Name: digestNoAssignee, Count: 2}, {Name: "Иван Петров", Count: 1}}, digest.OpenByAssignee)
+
+	require.Len(t, digest.Overdue, 2)
+	require.Equal(t, "TEST-4", digest.Overdue[0].Key)
+	require.True(t, digest.Overdue[0].Sla)
+	require.Equal(t, "TEST-3", digest.Overdue[1].Key)
+
+	// (5 дней + 6 часов) / 2
+	require.Equal(t, 63*time.Hour, digest.CycleTime)
+	// Переоткрытие 12-го не попадает в период
+	require.Equal(t, 1, digest.Reopened)
+	require.Equal(t, []DigestCount{{Name: "Иван Петров", Count: 1}}, digest.TopReopeners)
+
+	confluence := digest.RenderConfluence()
+	require.Contains(t, confluence, "<h2>Сводка по проекту TEST за 13.01.2025 — 19.01.2025</h2>")
+	require.Contains(t, confluence, "<tr><th>Среднее время цикла</th><td>2 д 15 ч</td></tr>")
+	require.Contains(t, confluence, `<ac:parameter ac:name="key">TEST-3</ac:parameter>`)
+	require.Contains(t, confluence, "Срок &lt;вчера&gt;")
+
+	telegram := digest.RenderTelegram()
+	require.Contains(t, telegram, "Создано: <b>3</b>, решено: <b>2</b>")
+	require.Contains(t, telegram, "• <code>TEST-3</code> Срок &lt;вчера&gt; (Иван Петров, 19.01.2025)")
+	require.NotContains(t, telegram, "<table>")
+
+	// История изменений приходит вместе с поиском, период задан смещением от текущего момента
+	require.Zero(t, api.changelogCalls)
+	require.Regexp(t, `^project = TEST AND created >= "-\d+m" AND created < "-\d+m"$`, api.queries[0])
+
+	// Неполная история в результатах поиска дозапрашивается
+	api.truncateHistory = true
+	again, err := BuildProjectDigest(context.Background(), api, "TEST", from, to)
+	require.NoError(t, err)
+	require.Equal(t, digest.CycleTime, again.CycleTime)
+	require.Equal(t, digest.Reopened, again.Reopened)
+	// TEST-1 — среди решённых и переоткрытых, у TEST-2 история из одной записи
+	require.Equal(t, 2, api.changelogCalls)
+}
+
+func TestDigestJqlTime(t *testing.T) {
+	now := time.Date(2025, 1, 20, 12, 0, 0, 0, time.UTC)
+	require.Equal(t, "-10080m", digestJqlTime(now.AddDate(0, 0, -7), now))
+	// Часовой пояс момента не влияет на запрос
+	require.Equal(t, "-60m", digestJqlTime(time.Date(2025, 1, 20, 14, 0, 0, 0, time.FixedZone("MSK", 3*3600)), now))
+	require.Equal(t, "30m", digestJqlTime(now.Add(30*time.Minute+20*time.Second), now))
+	require.Equal(t, "0m", digestJqlTime(now, now))
+}
@@ -1,6 +1,9 @@
 package jira
 
-import "strings"
+import (
+	"cmp"
+	"strings"
+)
 
 type WebhookIssue struct {
 	Timestamp      Timestamp    `json:"timestamp,omitzero"`
@@ -150,6 +153,7 @@ type IssueCheckBoxStatus struct {
 }
 
 type JiraUser struct {
+	AccountId   string `json:"accountId,omitzero"` // только Jira Cloud
 	Name        string `json:"name,omitzero"`
 	Key         string `json:"key,omitzero"`
 	Email       string `json:"emailAddress,omitzero"`
@@ -157,6 +161,11 @@ type JiraUser struct {
 	Active      bool   `json:"active,omitzero"`
 }
 
+// Identity — идентификатор пользователя для запросов: accountId в Jira Cloud, логин в Server/DC
+func (u JiraUser) Identity() string {
+	return cmp.Or(u.AccountId, u.Name)
+}
+
 type JiraProject struct {
 	IssueField
 	ProjectCategory IssueField `json:"projectCategory,omitzero"`
@@ -166,6 +175,7 @@ type JiraProject struct {
 type JiraComponent struct {
 	IssueField
 	Assignee  JiraUser `json:"assignee,omitzero"`
+	Lead      JiraUser `json:"lead,omitzero"`
 	Project   string   `json:"project,omitzero"`
 	ProjectId int      `json:"projectId,omitzero"`
 }
@@ -179,6 +189,22 @@ type ProjectVersion struct {
 	ProjectID       int    `json:"projectId"`
 }
 
+type Attachment struct {
+	ID       string   `json:"id,omitzero"`
+	Filename string   `json:"filename,omitzero"`
+	Author   JiraUser `json:"author,omitzero"`
+	Created  JiraTime `json:"created,omitzero"`
+	Size     int64    `json:"size,omitzero"`
+	MimeType string   `json:"mimeType,omitzero"`
+	Content  string   `json:"content,omitzero"` // ссылка на скачивание
+}
+
+type IssueLinkRequest struct {
+	Type         IssueField `json:"type"`
+	InwardIssue  IssueField `json:"inwardIssue"`
+	OutwardIssue IssueField `json:"outwardIssue"`
+}
+
 type IssueWatchersResponse struct {
 	Watchers []JiraUser `json:"watchers,omitzero"`
 }
@@ -214,6 +240,7 @@ type SearchRequest struct {
 	StartAt    int      `json:"startAt,omitzero"`
 	MaxResults int      `json:"maxResults,omitzero"`
 	Fields     []string `json:"fields,omitzero"`
+	Expand     []string `json:"expand,omitzero"`
 }
 
 type SearchResponse struct {
@@ -285,8 +312,19 @@ type MetaField struct {
 	Name            string       `json:"name"`
 	Required        bool         `json:"required"`
 	HasDefaultValue bool         `json:"hasDefaultValue"`
+	Schema          MetaSchema   `json:"schema,omitzero"`
 	AllowedValues   []IssueField `json:"allowedValues,omitempty"`
 	DefaultValue    IssueField   `json:"defaultValue,omitempty"`
+	cloud           bool
+}
+
+// MetaSchema — описание типа значения поля: type (string, option, array...), items — тип элементов массива
+type MetaSchema struct {
+	Type     string `json:"type,omitzero"`
+	Items    string `json:"items,omitzero"`
+	System   string `json:"system,omitzero"`
+	Custom   string `json:"custom,omitzero"`
+	CustomId int    `json:"customId,omitzero"`
 }
 
 func (i *IssueTypeMeta) GetCustomerIdByName(client, customerFieldId string) string {
@@ -318,3 +356,48 @@ func (i *IssueTypeMeta) GetCustomerOptions(customerFieldId string) []string {
 	}
 	return options
 }
+
+// agilePage — общий формат постраничного ответа Agile API (/rest/agile/1.0)
+type agilePage[T any] struct {
+	StartAt    int  `json:"startAt"`
+	MaxResults int  `json:"maxResults"`
+	Total      int  `json:"total"`
+	IsLast     bool `json:"isLast"`
+	Values     []T  `json:"values"`
+}
+
+type Board struct {
+	ID       int           `json:"id"`
+	Name     string        `json:"name"`
+	Type     string        `json:"type"` // scrum или kanban
+	Self     string        `json:"self,omitzero"`
+	Location BoardLocation `json:"location,omitzero"`
+}
+
+type BoardLocation struct {
+	ProjectId  int    `json:"projectId,omitzero"`
+	ProjectKey string `json:"projectKey,omitzero"`
+	Name       string `json:"name,omitzero"`
+}
+
+type Sprint struct {
+	ID            int      `json:"id"`
+	Name          string   `json:"name"`
+	State         string   `json:"state"` // SprintState
+	Goal          string   `json:"goal,omitzero"`
+	OriginBoardId int      `json:"originBoardId,omitzero"`
+	StartDate     JiraTime `json:"startDate,omitzero"`
+	EndDate       JiraTime `json:"endDate,omitzero"`
+	CompleteDate  JiraTime `json:"completeDate,omitzero"`
+	Self          string   `json:"self,omitzero"`
+}
+
+type MoveIssuesRequest struct {
+	Issues []string `json:"issues"`
+}
+
+type RankIssuesRequest struct {
+	Issues          []string `json:"issues"`
+	RankBeforeIssue string   `json:"rankBeforeIssue,omitzero"`
+	RankAfterIssue  string   `json:"rankAfterIssue,omitzero"`
+}
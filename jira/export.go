@@ -0,0 +1,379 @@
+package jira
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+)
+
+type ExportFormat string
+
+const (
+	ExportCSV        ExportFormat = "csv"
+	ExportXLSX       ExportFormat = "xlsx"
+	ExportJSONLines  ExportFormat = "jsonl"
+	exportTimeFormat              = "2006-01-02 15:04"
+)
+
+// Размер страницы поиска при выгрузке. С changelog Jira отдаёт тяжёлые ответы, поэтому страница меньше
+const (
+	exportPageSize          = 1000
+	exportChangelogPageSize = 100
+)
+
+// ExportRow — задача, из которой колонка берёт значение.
+// Fields — сырые поля из ответа Jira, в том числе кастомные, которых нет в FieldsIssue
+type ExportRow struct {
+	Issue  IssueJira
+	Fields map[string]any
+}
+
+// ExportColumn — колонка выгрузки. Fields — какие поля запросить у Jira,
+// Changelog — нужна ли история изменений, Value — значение ячейки
+type ExportColumn struct {
+	Header    string
+	Fields    []string
+	Changelog bool
+	Value     func(row ExportRow) any
+}
+
+// KeyColumn — ключ задачи
+func KeyColumn(header string) ExportColumn {
+	return ExportColumn{Header: header, Value: func(row ExportRow) any { return row.Issue.Key }}
+}
+
+// FieldColumn — значение стандартного или кастомного поля по его идентификатору
+func FieldColumn(header, fieldId string) ExportColumn {
+	return ExportColumn{
+		Header: header,
+		Fields: []string{fieldId},
+		Value:  func(row ExportRow) any { return row.Fields[fieldId] },
+	}
+}
+
+// ComputedColumn — значение, вычисляемое по типизированной задаче
+func ComputedColumn(header string, fields []string, value func(issue IssueJira) any) ExportColumn {
+	return ExportColumn{Header: header, Fields: fields, Value: func(row ExportRow) any { return value(row.Issue) }}
+}
+
+// ChangelogColumn — значение, вычисляемое по истории изменений задачи
+func ChangelogColumn(header string, value func(issue IssueJira, histories []ChangeLog) any) ExportColumn {
+	return ExportColumn{
+		Header:    header,
+		Changelog: true,
+		Value:     func(row ExportRow) any { return value(row.Issue, row.Issue.Changelog.Histories) },
+	}
+}
+
+// StatusEnteredColumn — когда задача впервые перешла в статус statusId
+func StatusEnteredColumn(header, statusId string) ExportColumn {
+	return ChangelogColumn(header, func(_ IssueJira, histories []ChangeLog) any {
+		for _, history := range histories {
+			if item := history.FindItemByField(Changelog.SingleItem.Field.Status); item.To == statusId {
+				return history.Created
+			}
+		}
+		return nil
+	})
+}
+
+// ExportIssues — выгружает задачи по JQL в w постранично, не накапливая всю выборку в памяти
+func (j *jira) ExportIssues(ctx context.Context, query string, columns []ExportColumn, format ExportFormat, w io.Writer) error {
+	if query == "" {
+		return fmt.Errorf("query is empty")
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("columns are empty")
+	}
+	headers := make([]string, 0, len(columns))
+	var fields []string
+	withChangelog := false
+	for _, column := range columns {
+		headers = append(headers, column.Header)
+		for _, field := range column.Fields {
+			fields = appendUniqueString(fields, field)
+		}
+		withChangelog = withChangelog || column.Changelog
+	}
+	// Без явного списка полей Jira вернёт только навигационные, а не все
+	if len(fields) == 0 {
+		fields = []string{Issue.Fields.Summary}
+	}
+
+	out, err := newExportWriter(format, w, headers)
+	if err != nil {
+		return err
+	}
+	pageSize := exportPageSize
+	var expand []string
+	if withChangelog {
+		pageSize = exportChangelogPageSize
+		expand = []string{"changelog"}
+	}
+
+	writePage := func(issues []json.RawMessage) error {
+		for _, raw := range issues {
+			row, err := newExportRow(raw)
+			if err != nil {
+				return err
+			}
+			cells := make([]string, 0, len(columns))
+			for _, column := range columns {
+				cells = append(cells, formatExportCell(column.Value(row)))
+			}
+			if err := out.WriteRow(cells); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if j.Cloud {
+		req := SearchRequest{Jql: query, MaxResults: pageSize, Fields: fields, Expand: expand}
+		if err := searchCloudPages(ctx, j, req, writePage); err != nil {
+			return err
+		}
+		return out.Close()
+	}
+	for offset := 0; ; {
+		req := SearchRequest{Jql: query, StartAt: offset, MaxResults: pageSize, Fields: fields, Expand: expand}
+		var resp rawSearchResponse
+		err := requests.
+			URL(fmt.Sprintf("%s/search", j.BaseUrl)).
+			BodySerializer(j.marshal, &req).
+			Config(j.config).
+			ToDeserializer(j.unmarshal, &resp).
+			AddValidator(validateStatus).
+			Fetch(ctx)
+		if err != nil {
+			return err
+		}
+		if err := writePage(resp.Issues); err != nil {
+			return err
+		}
+		offset += len(resp.Issues)
+		if len(resp.Issues) == 0 || offset >= resp.Total {
+			break
+		}
+	}
+	return out.Close()
+}
+
+type rawSearchResponse struct {
+	Total  int               `json:"total"`
+	Issues []json.RawMessage `json:"issues"`
+}
+
+func newExportRow(raw json.RawMessage) (ExportRow, error) {
+	var row ExportRow
+	if err := json.Unmarshal(raw, &row.Issue); err != nil {
+		return ExportRow{}, err
+	}
+	var fields struct {
+		Fields map[string]any `json:"fields"`
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return ExportRow{}, err
+	}
+	row.Fields = fields.Fields
+	return row, nil
+}
+
+// formatExportCell — человекочитаемое значение ячейки: пользователь — отображаемое имя,
+// значение из списка — его название, даты — в формате exportTimeFormat
+func formatExportCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		if t, err := time.Parse(TimeFormatJira, v); err == nil {
+			return t.Local().Format(exportTimeFormat)
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case JiraTime:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Local().Format(exportTimeFormat)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Local().Format(exportTimeFormat)
+	case JiraUser:
+		return cmp.Or(v.DisplayName, v.Name, v.Key)
+	case IssueField:
+		return cmp.Or(v.Value, v.Name, v.Key, v.ID)
+	case JiraProject:
+		return cmp.Or(v.Key, v.Name, v.ID)
+	case JiraComponent:
+		return v.Name
+	case map[string]any:
+		for _, key := range []string{"displayName", "value", "name", "key", "id"} {
+			if s, ok := v[key].(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	case []string:
+		return strings.Join(v, ", ")
+	case []any:
+		return joinExportCells(v)
+	case []JiraUser:
+		return joinExportCells(v)
+	case []IssueField:
+		return joinExportCells(v)
+	case []JiraComponent:
+		return joinExportCells(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func joinExportCells[T any](values []T) string {
+	cells := make([]string, 0, len(values))
+	for _, value := range values {
+		if cell := formatExportCell(value); cell != "" {
+			cells = append(cells, cell)
+		}
+	}
+	return strings.Join(cells, ", ")
+}
+
+type exportWriter interface {
+	WriteRow(cells []string) error
+	Close() error
+}
+
+func newExportWriter(format ExportFormat, w io.Writer, headers []string) (exportWriter, error) {
+	switch format {
+	case ExportCSV:
+		return newCsvExportWriter(w, headers)
+	case ExportJSONLines:
+		return &jsonLinesExportWriter{enc: json.NewEncoder(w), headers: headers}, nil
+	case ExportXLSX:
+		return newXlsxExportWriter(w, headers)
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCsvExportWriter(w io.Writer, headers []string) (*csvExportWriter, error) {
+	out := &csvExportWriter{w: csv.NewWriter(w)}
+	return out, out.WriteRow(headers)
+}
+
+func (c *csvExportWriter) WriteRow(cells []string) error {
+	return c.w.Write(cells)
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonLinesExportWriter — по объекту на строку, ключи — заголовки колонок
+type jsonLinesExportWriter struct {
+	enc     *json.Encoder
+	headers []string
+}
+
+func (j *jsonLinesExportWriter) WriteRow(cells []string) error {
+	row := make(map[string]string, len(cells))
+	for i, cell := range cells {
+		row[j.headers[i]] = cell
+	}
+	return j.enc.Encode(row)
+}
+
+func (j *jsonLinesExportWriter) Close() error {
+	return nil
+}
+
+// xlsxExportWriter — минимальная книга Excel с одним листом. Строки пишутся прямо в zip-поток
+type xlsxExportWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Issues" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+func newXlsxExportWriter(w io.Writer, headers []string) (*xlsxExportWriter, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+	// Лист создаётся последним: zip.Writer пишет файлы последовательно, и в лист можно дописывать строки
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+	out := &xlsxExportWriter{zip: zw, sheet: sheet}
+	return out, out.WriteRow(headers)
+}
+
+func (x *xlsxExportWriter) WriteRow(cells []string) error {
+	var b strings.Builder
+	b.WriteString("<row>")
+	for _, cell := range cells {
+		b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(&b, []byte(cell)); err != nil {
+			return err
+		}
+		b.WriteString("</t></is></c>")
+	}
+	b.WriteString("</row>")
+	_, err := io.WriteString(x.sheet, b.String())
+	return err
+}
+
+func (x *xlsxExportWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	return x.zip.Close()
+}
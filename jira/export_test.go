@@ -0,0 +1,99 @@
+package jira
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const exportTestIssue = `{"key": "TEST-1", "fields": {
+	"summary": "Выгрузка <в> Excel",
+	"assignee": {"name": "testuser", "displayName": "Test User"},
+	"customfield_14082": {"id": "12669", "value": "Сбербанк"},
+	"customfield_99999": [{"value": "A"}, {"value": "B"}],
+	"components": [{"name": "Jira"}, {"name": "Мониторинг"}]
+}, "changelog": {"histories": [
+	{"created": "2025-01-17T12:03:44.000+0300", "items": [{"field": "status", "to": "10424"}]},
+	{"created": "2025-01-20T10:00:00.000+0300", "items": [{"field": "status", "to": "10520"}]}
+]}}`
+
+func newExportTestServer(t *testing.T, wantExpand []string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		var req SearchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, wantExpand, req.Expand)
+		w.Header().Set("Content-Type", "application/json")
+		_, err := io.WriteString(w, `{"total": 1, "issues": [`+exportTestIssue+`]}`)
+		require.NoError(t, err)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExportIssues(t *testing.T) {
+	columns := []ExportColumn{
+		KeyColumn("Ключ"),
+		FieldColumn("Тема", Issue.Fields.Summary),
+		FieldColumn("Исполнитель", Issue.Fields.Assignee),
+		FieldColumn("Заказчик", Issue.Fields.Customer),
+		FieldColumn("Мультисписок", "customfield_99999"),
+		ComputedColumn("Компоненты", []string{Issue.Fields.Components}, func(issue IssueJira) any { return issue.Fields.Components }),
+	}
+
+	t.Run("01. CSV", func(t *testing.T) {
+		j := &jira{BaseUrl: newExportTestServer(t, nil).URL, Token: "token"}
+		var buf bytes.Buffer
+		require.NoError(t, j.ExportIssues(context.Background(), "project = TEST", columns, ExportCSV, &buf))
+		require.Equal(t, "Ключ,Тема,Исполнитель,Заказчик,Мультисписок,Компоненты\n"+
+			"TEST-1,Выгрузка <в> Excel,Test User,Сбербанк,\"A, B\",\"Jira, Мониторинг\"\n", buf.String())
+	})
+
+	t.Run("02. JSON Lines с changelog", func(t *testing.T) {
+		j := &jira{BaseUrl: newExportTestServer(t, []string{"changelog"}).URL, Token: "token"}
+		var buf bytes.Buffer
+		cols := []ExportColumn{KeyColumn("key"), StatusEnteredColumn("selected", Issue.Status.Selected)}
+		require.NoError(t, j.ExportIssues(context.Background(), "project = TEST", cols, ExportJSONLines, &buf))
+		var got map[string]string
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+		require.Equal(t, "TEST-1", got["key"])
+		require.NotEmpty(t, got["selected"])
+	})
+
+	t.Run("03. XLSX", func(t *testing.T) {
+		j := &jira{BaseUrl: newExportTestServer(t, nil).URL, Token: "token"}
+		var buf bytes.Buffer
+		require.NoError(t, j.ExportIssues(context.Background(), "project = TEST", columns, ExportXLSX, &buf))
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		var sheet string
+		for _, f := range zr.File {
+			if f.Name != "xl/worksheets/sheet1.xml" {
+				continue
+			}
+			rc, err := f.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(rc)
+			require.NoError(t, err)
+			sheet = string(data)
+		}
+		require.True(t, strings.HasSuffix(sheet, xlsxSheetEnd))
+		require.Contains(t, sheet, "Выгрузка &lt;в&gt; Excel")
+		require.Equal(t, 2, strings.Count(sheet, "<row>"))
+	})
+
+	t.Run("04. Неизвестный формат", func(t *testing.T) {
+		j := &jira{BaseUrl: newExportTestServer(t, nil).URL, Token: "token"}
+		require.Error(t, j.ExportIssues(context.Background(), "project = TEST", columns, "pdf", io.Discard))
+	})
+}
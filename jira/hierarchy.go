@@ -0,0 +1,160 @@
+package jira
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Поля, которые запрашиваются для каждой задачи дерева
+var issueTreeFields = []string{
+	Issue.Fields.Summary,
+	Issue.Fields.Status,
+	Issue.Fields.IssueType,
+	Issue.Fields.Resolution,
+	Issue.Fields.StoryPoints,
+	Issue.Fields.BusinessValue,
+	Issue.Fields.Parent,
+	Issue.Fields.SubTasks,
+}
+
+// IssueNode — узел дерева задач: эпик → задачи эпика → подзадачи
+type IssueNode struct {
+	Issue    IssueJira
+	Depth    int
+	Children []*IssueNode
+}
+
+// IssueProgress — прогресс по статусам листовых задач дерева
+type IssueProgress struct {
+	Total    int
+	Done     int            // задачи с заполненной резолюцией
+	ByStatus map[string]int // количество задач по названию статуса
+}
+
+// Percent — доля завершенных задач в процентах
+func (p IssueProgress) Percent() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Done) * 100 / float64(p.Total)
+}
+
+// CreateSubtasks — создаёт подзадачи родительской задачи. Проект и тип (подзадача) по умолчанию берутся от родителя.
+// При ошибке возвращает уже созданные подзадачи
+func (j *jira) CreateSubtasks(ctx context.Context, parentKey string, subtasks []FieldsIssue) ([]CreatedIssueResponse, error) {
+	if strings.TrimSpace(parentKey) == "" {
+		return nil, fmt.Errorf("parentKey is empty")
+	}
+	if len(subtasks) == 0 {
+		return nil, fmt.Errorf("subtasks are empty")
+	}
+	parent, err := j.GetIssueById(ctx, parentKey, Issue.Fields.Project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent issue %s: %w", parentKey, err)
+	}
+	created := make([]CreatedIssueResponse, 0, len(subtasks))
+	for _, subtask := range subtasks {
+		subtask.Parent = &IssueJira{Key: parentKey}
+		if subtask.Project.ID == "" && subtask.Project.Key == "" {
+			subtask.Project = JiraProject{IssueField: IssueField{Key: parent.Fields.Project.Key}}
+		}
+		if subtask.IssueType.ID == "" && subtask.IssueType.Name == "" {
+			subtask.IssueType = IssueField{ID: Issue.Type.SubTask}
+		}
+		resp, err := j.CreateIssue(ctx, subtask)
+		if err != nil {
+			return created, fmt.Errorf("failed to create subtask %q of %s: %w", subtask.Summary, parentKey, err)
+		}
+		created = append(created, resp)
+	}
+	return created, nil
+}
+
+// GetIssueTree — строит дерево задач от rootKey вглубь на depth уровней (0 — только сама задача).
+// Для эпиков потомки ищутся через Epic Link, для остальных задач — подзадачи
+func (j *jira) GetIssueTree(ctx context.Context, rootKey string, depth int) (*IssueNode, error) {
+	root, err := j.GetIssueById(ctx, rootKey, issueTreeFields...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root issue %s: %w", rootKey, err)
+	}
+	node := &IssueNode{Issue: root}
+	if err := j.fillIssueTree(ctx, node, depth); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func (j *jira) fillIssueTree(ctx context.Context, node *IssueNode, depth int) error {
+	if node.Depth >= depth {
+		return nil
+	}
+	var jql string
+	switch {
+	case node.Issue.Fields.IssueType.ID == Issue.Type.Epic:
+		jql = fmt.Sprintf(`"Epic Link" = %s ORDER BY Rank`, node.Issue.Key)
+	case len(node.Issue.Fields.SubTasks) > 0:
+		jql = fmt.Sprintf(`parent = %s ORDER BY created`, node.Issue.Key)
+	default:
+		return nil
+	}
+	children, err := j.SearchAllTasks(ctx, jql, issueTreeFields...)
+	if err != nil {
+		return fmt.Errorf("failed to get children of %s: %w", node.Issue.Key, err)
+	}
+	for _, child := range children {
+		childNode := &IssueNode{Issue: child, Depth: node.Depth + 1}
+		if err := j.fillIssueTree(ctx, childNode, depth); err != nil {
+			return err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return nil
+}
+
+// Walk — обходит дерево в глубину, начиная с текущего узла
+func (n *IssueNode) Walk(fn func(node *IssueNode)) {
+	fn(n)
+	for _, child := range n.Children {
+		child.Walk(fn)
+	}
+}
+
+// RollUp — агрегирует значение вверх по дереву: если у потомков есть ненулевая сумма,
+// берется она, иначе собственное значение узла. Так оценка эпика не складывается с оценками его задач
+func (n *IssueNode) RollUp(value func(issue IssueJira) float64) float64 {
+	var sum float64
+	for _, child := range n.Children {
+		sum += child.RollUp(value)
+	}
+	if sum != 0 {
+		return sum
+	}
+	return value(n.Issue)
+}
+
+// StoryPoints — Story Points поддерева
+func (n *IssueNode) StoryPoints() float64 {
+	return n.RollUp(func(issue IssueJira) float64 { return issue.Fields.StoryPoints })
+}
+
+// BusinessValue — Business Value поддерева
+func (n *IssueNode) BusinessValue() float64 {
+	return n.RollUp(func(issue IssueJira) float64 { return issue.Fields.BusinessValue })
+}
+
+// Progress — прогресс по статусам листовых задач поддерева
+func (n *IssueNode) Progress() IssueProgress {
+	progress := IssueProgress{ByStatus: map[string]int{}}
+	n.Walk(func(node *IssueNode) {
+		if len(node.Children) > 0 {
+			return
+		}
+		progress.Total++
+		progress.ByStatus[node.Issue.Fields.Status.Name]++
+		if node.Issue.Fields.Resolution.ID != "" {
+			progress.Done++
+		}
+	})
+	return progress
+}
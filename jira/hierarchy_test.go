@@ -0,0 +1,64 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIssueTree(t *testing.T) {
+	done := IssueField{ID: Issue.Resolution.Fixed}
+	epic := IssueJira{Key: "EPIC-1", Fields: FieldsIssue{IssueType: IssueField{ID: Issue.Type.Epic}, StoryPoints: 100}}
+	childrenByJql := map[string][]IssueJira{
+		`"Epic Link" = EPIC-1 ORDER BY Rank`: {
+			{Key: "KEY-1", Fields: FieldsIssue{StoryPoints: 5, BusinessValue: 3, Status: IssueField{Name: "Done"}, Resolution: done,
+				SubTasks: []IssueJira{{Key: "KEY-3"}}}},
+			{Key: "KEY-2", Fields: FieldsIssue{StoryPoints: 8, BusinessValue: 2, Status: IssueField{Name: "Selected"}}},
+		},
+		`parent = KEY-1 ORDER BY created`: {
+			{Key: "KEY-3", Fields: FieldsIssue{Status: IssueField{Name: "Done"}, Resolution: done}},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/EPIC-1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(epic))
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		var req SearchRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		issues, ok := childrenByJql[req.Jql]
+		require.True(t, ok, "unexpected jql %q", req.Jql)
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(SearchResponse{Total: len(issues), Issues: issues}))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	j := &jira{BaseUrl: srv.URL, Token: "token"}
+
+	tree, err := j.GetIssueTree(context.Background(), "EPIC-1", 2)
+	require.NoError(t, err)
+	require.Len(t, tree.Children, 2)
+	require.Len(t, tree.Children[0].Children, 1)
+	require.Equal(t, 2, tree.Children[0].Children[0].Depth)
+
+	// Оценка эпика заменяется суммой оценок задач, у подзадачи оценки нет — берется оценка задачи
+	require.Equal(t, 13.0, tree.StoryPoints())
+	require.Equal(t, 5.0, tree.BusinessValue())
+
+	progress := tree.Progress()
+	require.Equal(t, 2, progress.Total)
+	require.Equal(t, 1, progress.Done)
+	require.Equal(t, 50.0, progress.Percent())
+	require.Equal(t, map[string]int{"Done": 1, "Selected": 1}, progress.ByStatus)
+
+	shallow, err := j.GetIssueTree(context.Background(), "EPIC-1", 1)
+	require.NoError(t, err)
+	require.Empty(t, shallow.Children[0].Children)
+}
@@ -1,10 +1,17 @@
 package jira
 
-import "context"
+import (
+	"context"
+	"io"
+)
 
 type ApiJira interface {
 	SearchTasks(ctx context.Context, query string, pageSize, offset int, fields ...string) (SearchResponse, error)
 	SearchAllTasks(ctx context.Context, query string, fields ...string) ([]IssueJira, error)
+	// SearchAllTasksExpand is SearchAllTasks with expand, e.g. changelog, returned together with the search results.
+	SearchAllTasksExpand(ctx context.Context, query string, expand []string, fields ...string) ([]IssueJira, error)
+	// ExportIssues streams search results as CSV, XLSX or JSON Lines, one row per issue.
+	ExportIssues(ctx context.Context, query string, columns []ExportColumn, format ExportFormat, w io.Writer) error
 	GetIssueById(ctx context.Context, issueId string, fields ...string) (IssueJira, error)
 
 	GetIssueComments(ctx context.Context, issueKey string) ([]IssueComment, error)
@@ -20,12 +27,28 @@ type ApiJira interface {
 
 	CreateIssueFromMap(ctx context.Context, req map[string]any) (CreatedIssueResponse, error)
 	CreateIssue(ctx context.Context, req FieldsIssue) (CreatedIssueResponse, error)
+	// CreateIssueFromValues builds Jira field shapes from human-friendly values using create-meta and validates them before creating.
+	CreateIssueFromValues(ctx context.Context, projectKey, issueTypeId string, values map[string]any) (CreatedIssueResponse, error)
+	// ValidateCreateIssue is a pre-flight check of required fields and allowed values using create-meta.
+	ValidateCreateIssue(ctx context.Context, req FieldsIssue) error
+
+	// CreateSubtasks creates subtasks of the parent issue; project and subtask type default to the parent's.
+	CreateSubtasks(ctx context.Context, parentKey string, subtasks []FieldsIssue) ([]CreatedIssueResponse, error)
+	// GetIssueTree walks epic → issues in epic → subtasks down to the given depth.
+	GetIssueTree(ctx context.Context, rootKey string, depth int) (*IssueNode, error)
 
 	UpdateIssueFromMap(ctx context.Context, issueKey string, req map[string]any) error
 	UpdateIssue(ctx context.Context, issueKey string, req FieldsIssue) error
 	UpdateIssueAssignee(ctx context.Context, issueKey string, assigneeName string) error
 
 	AddLabel(ctx context.Context, issueKey string, label string) error
+	LinkIssues(ctx context.Context, linkType, inwardKey, outwardKey string) error
+
+	DownloadAttachment(ctx context.Context, attachment Attachment) ([]byte, error)
+	AddAttachment(ctx context.Context, issueKey, filename string, data []byte) ([]Attachment, error)
+
+	// CloneIssue copies the issue to another project/type translating fields by the target create-meta.
+	CloneIssue(ctx context.Context, issueKey, targetProject, targetTypeId string, fieldMapping map[string]string, opts CloneOptions) (CloneResult, error)
 
 	CommentIssue(ctx context.Context, issueKey, comment string) error
 
@@ -35,4 +58,15 @@ type ApiJira interface {
 	TransitionIssueWithComment(ctx context.Context, issueKey, transitionID, comment string) error
 	// TransitionToStatus is a high-level transition method by target status ID.
 	TransitionToStatus(ctx context.Context, issueKey, targetStatusId string) error
+
+	// Agile API: доски, спринты, бэклог и ранжирование
+	GetBoards(ctx context.Context, projectKey string) ([]Board, error)
+	GetBoardSprints(ctx context.Context, boardId int, states ...string) ([]Sprint, error)
+	GetActiveSprint(ctx context.Context, boardId int) (Sprint, error)
+	GetSprintIssues(ctx context.Context, sprintId int, fields ...string) ([]IssueJira, error)
+	MoveIssuesToSprint(ctx context.Context, sprintId int, issueKeys ...string) error
+	MoveIssuesToBacklog(ctx context.Context, issueKeys ...string) error
+	RankIssues(ctx context.Context, issueKeys []string, rankBeforeIssue, rankAfterIssue string) error
+	// PlanIssueToActiveSprint moves the issue to Selected status and adds it to the board's active sprint.
+	PlanIssueToActiveSprint(ctx context.Context, issueKey string, boardId int) error
 }
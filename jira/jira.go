@@ -1,10 +1,15 @@
 package jira
 
 import (
+	"bytes"
 	"cmp"
 	"context"
 	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/carlmjohnson/requests"
@@ -13,10 +18,47 @@ import (
 type jira struct {
 	BaseUrl string
 	Token   string
+	User    string // логин для Basic-авторизации (email в Jira Cloud), если пустой — авторизация по Bearer-токену
+	Cloud   bool   // режим Jira Cloud: REST API v3, accountId, ADF и поиск через /search/jql
+
+	observer Observer
+	dryRun   bool
+	logger   *slog.Logger
+
+	cloudTokens cloudTokenCache // см. searchTasksCloud
+}
+
+func NewJira(baseUrl, token string, opts ...Option) ApiJira {
+	j := &jira{BaseUrl: strings.TrimRight(baseUrl, "/"), Token: token}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
+}
+
+// NewJiraCloud — клиент Jira Cloud. siteUrl вида https://company.atlassian.net, авторизация по email и API-токену
+func NewJiraCloud(siteUrl, email, apiToken string, opts ...Option) ApiJira {
+	baseUrl := strings.TrimRight(siteUrl, "/")
+	if !strings.Contains(baseUrl, "/rest/api/") {
+		baseUrl += restApiV3Path
+	}
+	j := &jira{BaseUrl: baseUrl, Token: apiToken, User: email, Cloud: true}
+	for _, opt := range opts {
+		opt(j)
+	}
+	return j
 }
 
-func NewJira(baseUrl, token string) ApiJira {
-	return &jira{BaseUrl: strings.TrimRight(baseUrl, "/"), Token: token}
+// config — общие настройки всех запросов к Jira
+func (j *jira) config(rb *requests.Builder) {
+	if j.observer != nil || j.dryRun {
+		rb.Transport(observingTransport{j: j, next: http.DefaultTransport})
+	}
+	if j.User != "" {
+		rb.BasicAuth(j.User, j.Token)
+		return
+	}
+	rb.Bearer(j.Token)
 }
 
 // GetFields — возвращает полный список полей в Jira
@@ -24,8 +66,8 @@ func (j *jira) GetFields(ctx context.Context) ([]IssueField, error) {
 	var fields []IssueField
 	err := requests.
 		URL(fmt.Sprintf("%s/field", j.BaseUrl)).
-		Bearer(j.Token).
-		ToJSON(&fields).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &fields).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -38,8 +80,8 @@ func (j *jira) GetIssueComments(ctx context.Context, issueKey string) ([]IssueCo
 	var resp IssueCommentsResponse
 	err := requests.
 		URL(fmt.Sprintf("%s/issue/%s/comment", j.BaseUrl, issueKey)).
-		Bearer(j.Token).
-		ToJSON(&resp).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -52,8 +94,8 @@ func (j *jira) GetIssueWatchers(ctx context.Context, issueKey string) ([]JiraUse
 	var resp IssueWatchersResponse
 	err := requests.
 		URL(fmt.Sprintf("%s/issue/%s/watchers", j.BaseUrl, issueKey)).
-		Bearer(j.Token).
-		ToJSON(&resp).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -66,8 +108,8 @@ func (j *jira) GetProjectVersions(ctx context.Context, projectKey string) ([]Pro
 	var resp []ProjectVersion
 	err := requests.
 		URL(fmt.Sprintf("%s/project/%s/versions", j.BaseUrl, projectKey)).
-		Bearer(j.Token).
-		ToJSON(&resp).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -83,8 +125,8 @@ func (j *jira) GetIssueById(ctx context.Context, issueId string, fields ...strin
 	var resp IssueJira
 	req := requests.
 		URL(fmt.Sprintf("%s/issue/%s", j.BaseUrl, issueId)).
-		Bearer(j.Token).
-		ToJSON(&resp).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &resp).
 		AddValidator(validateStatus)
 	// Если поля указаны, добавляем их в URL через запятую
 	if len(fields) > 0 {
@@ -99,10 +141,14 @@ func (j *jira) GetIssueById(ctx context.Context, issueId string, fields ...strin
 
 func (j *jira) GetUserByKey(ctx context.Context, userKey string) (JiraUser, error) {
 	var resp JiraUser
+	param := "key"
+	if j.Cloud {
+		param = "accountId"
+	}
 	err := requests.
-		URL(fmt.Sprintf("%s/user?key=%s", j.BaseUrl, url.QueryEscape(userKey))).
-		Bearer(j.Token).
-		ToJSON(&resp).
+		URL(fmt.Sprintf("%s/user?%s=%s", j.BaseUrl, param, url.QueryEscape(userKey))).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -115,8 +161,8 @@ func (j *jira) GetIssueChangelog(ctx context.Context, issueId string) ([]ChangeL
 	var resp IssueJira
 	err := requests.
 		URL(fmt.Sprintf("%s/issue/%s?expand=changelog", j.BaseUrl, issueId)).
-		Bearer(j.Token).
-		ToJSON(&resp).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -125,12 +171,17 @@ func (j *jira) GetIssueChangelog(ctx context.Context, issueId string) ([]ChangeL
 	return resp.Changelog.Histories, nil
 }
 
+// UpdateIssueAssignee — назначить исполнителя. В Jira Cloud assigneeName — это accountId
 func (j *jira) UpdateIssueAssignee(ctx context.Context, issueKey, assigneeName string) error {
+	assignee := JiraUser{Name: assigneeName}
+	if j.Cloud {
+		assignee = JiraUser{AccountId: assigneeName}
+	}
 	return requests.
 		URL(fmt.Sprintf("%s/issue/%s/assignee", j.BaseUrl, issueKey)).
 		Put().
-		Bearer(j.Token).
-		BodyJSON(JiraUser{Name: assigneeName}).
+		Config(j.config).
+		BodySerializer(j.marshal, assignee).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 }
@@ -148,8 +199,8 @@ func (j *jira) TransitionIssueWithComment(ctx context.Context, issueKey, transit
 	return requests.
 		URL(fmt.Sprintf("%s/issue/%s/transitions", j.BaseUrl, issueKey)).
 		Post().
-		Bearer(j.Token).
-		BodyJSON(req).
+		Config(j.config).
+		BodySerializer(j.marshal, req).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 }
@@ -216,8 +267,8 @@ func (j *jira) getIssueTransitions(ctx context.Context, issueKey string) ([]Tran
 	var meta TransitionsResponse
 	err := requests.
 		URL(fmt.Sprintf("%s/issue/%s/transitions", j.BaseUrl, issueKey)).
-		Bearer(j.Token).
-		ToJSON(&meta).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &meta).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -237,33 +288,106 @@ func (j *jira) transitionIssueToStatus(ctx context.Context, issueKey string, tra
 	return j.TransitionIssueWithComment(ctx, issueKey, transition.ID, transitionComment())
 }
 
+// LinkIssues — связать задачи: inwardKey <связь> outwardKey, например "Cloners": inwardKey клонирует outwardKey
+func (j *jira) LinkIssues(ctx context.Context, linkType, inwardKey, outwardKey string) error {
+	if strings.TrimSpace(inwardKey) == "" || strings.TrimSpace(outwardKey) == "" {
+		return fmt.Errorf("issue keys are empty")
+	}
+	req := IssueLinkRequest{
+		Type:         IssueField{Name: linkType},
+		InwardIssue:  IssueField{Key: inwardKey},
+		OutwardIssue: IssueField{Key: outwardKey},
+	}
+	return requests.
+		URL(fmt.Sprintf("%s/issueLink", j.BaseUrl)).
+		Post().
+		Config(j.config).
+		BodySerializer(j.marshal, req).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+}
+
+// DownloadAttachment — скачать содержимое вложения задачи
+func (j *jira) DownloadAttachment(ctx context.Context, attachment Attachment) ([]byte, error) {
+	if attachment.Content == "" {
+		return nil, fmt.Errorf("attachment %s has no content url", attachment.ID)
+	}
+	var buf bytes.Buffer
+	err := requests.
+		URL(attachment.Content).
+		Config(j.config).
+		ToBytesBuffer(&buf).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// AddAttachment — прикрепить файл к задаче
+func (j *jira) AddAttachment(ctx context.Context, issueKey, filename string, data []byte) ([]Attachment, error) {
+	if strings.TrimSpace(issueKey) == "" {
+		return nil, fmt.Errorf("issueKey is empty")
+	}
+	var created []Attachment
+	err := requests.
+		URL(fmt.Sprintf("%s/issue/%s/attachments", j.BaseUrl, issueKey)).
+		Post().
+		Config(j.config).
+		Header("X-Atlassian-Token", "no-check").
+		Config(requests.BodyMultipart("", func(multi *multipart.Writer) error {
+			part, err := multi.CreateFormFile("file", filename)
+			if err != nil {
+				return err
+			}
+			_, err = part.Write(data)
+			return err
+		})).
+		ToDeserializer(j.unmarshal, &created).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
 func (j *jira) CommentIssue(ctx context.Context, issueKey, comment string) error {
 	return requests.
 		URL(fmt.Sprintf("%s/issue/%s/comment", j.BaseUrl, issueKey)).
 		Post().
-		Bearer(j.Token).
-		BodyJSON(IssueComment{Body: comment}).
+		Config(j.config).
+		BodySerializer(j.marshal, IssueComment{Body: comment}).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 }
 
-// SearchTasks — постраничный поиск задач по JQL запросу, pageSize по умолчанию 50
+// SearchTasks — постраничный поиск задач по JQL запросу, pageSize по умолчанию 50.
+// В Jira Cloud total не возвращается, см. searchTasksCloud
 func (j *jira) SearchTasks(ctx context.Context, query string, pageSize, offset int, fields ...string) (SearchResponse, error) {
-	req := SearchRequest{
+	if query == "" {
+		return SearchResponse{}, fmt.Errorf("query is empty")
+	}
+	if j.Cloud {
+		return j.searchTasksCloud(ctx, query, cmp.Or(pageSize, 50), offset, fields...)
+	}
+	return j.searchPage(ctx, SearchRequest{
 		Jql:        query,
 		StartAt:    offset,
 		MaxResults: cmp.Or(pageSize, 50),
 		Fields:     fields,
-	}
+	})
+}
+
+// searchPage — одна страница /search в Server/DC
+func (j *jira) searchPage(ctx context.Context, req SearchRequest) (SearchResponse, error) {
 	var resp SearchResponse
-	if query == "" {
-		return SearchResponse{}, fmt.Errorf("query is empty")
-	}
 	err := requests.
 		URL(fmt.Sprintf("%s/search", j.BaseUrl)).
-		BodyJSON(&req).
-		Bearer(j.Token).
-		ToJSON(&resp).
+		BodySerializer(j.marshal, &req).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -274,14 +398,34 @@ func (j *jira) SearchTasks(ctx context.Context, query string, pageSize, offset i
 
 // SearchAllTasks — поиск всех задач по JQL запросу
 func (j *jira) SearchAllTasks(ctx context.Context, query string, fields ...string) ([]IssueJira, error) {
+	return j.SearchAllTasksExpand(ctx, query, nil, fields...)
+}
+
+// SearchAllTasksExpand — SearchAllTasks с expand, например changelog: история изменений приходит
+// вместе с результатами поиска, без отдельного запроса на каждую задачу
+func (j *jira) SearchAllTasksExpand(ctx context.Context, query string, expand []string, fields ...string) ([]IssueJira, error) {
 	if query == "" {
 		return nil, fmt.Errorf("query is empty")
 	}
-	const pageSize = 1000
+	if j.Cloud {
+		var all []IssueJira
+		err := searchCloudPages(ctx, j, SearchRequest{Jql: query, Fields: fields, Expand: expand}, func(issues []IssueJira) error {
+			all = append(all, issues...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return all, nil
+	}
+	pageSize := exportPageSize
+	if slices.Contains(expand, "changelog") {
+		pageSize = exportChangelogPageSize
+	}
 	offset := 0
 	var all []IssueJira
 	for {
-		resp, err := j.SearchTasks(ctx, query, pageSize, offset, fields...)
+		resp, err := j.searchPage(ctx, SearchRequest{Jql: query, StartAt: offset, MaxResults: pageSize, Fields: fields, Expand: expand})
 		if err != nil {
 			return nil, err
 		}
@@ -304,8 +448,8 @@ func (j *jira) UpdateIssueFromMap(ctx context.Context, issueKey string, req map[
 	return requests.
 		URL(fmt.Sprintf("%s/issue/%s", j.BaseUrl, issueKey)).
 		Put().
-		Bearer(j.Token).
-		BodyJSON(UpsertIssueRequestFromMap{Fields: req}).
+		Config(j.config).
+		BodySerializer(j.marshal, UpsertIssueRequestFromMap{Fields: req}).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 }
@@ -319,8 +463,8 @@ func (j *jira) UpdateIssue(ctx context.Context, issueKey string, req FieldsIssue
 	return requests.
 		URL(fmt.Sprintf("%s/issue/%s", j.BaseUrl, issueKey)).
 		Put().
-		Bearer(j.Token).
-		BodyJSON(UpsertIssueRequest{Fields: req}).
+		Config(j.config).
+		BodySerializer(j.marshal, UpsertIssueRequest{Fields: req}).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 }
@@ -333,8 +477,8 @@ func (j *jira) AddLabel(ctx context.Context, issueKey string, label string) erro
 	return requests.
 		URL(fmt.Sprintf("%s/issue/%s", j.BaseUrl, issueKey)).
 		Put().
-		Bearer(j.Token).
-		BodyJSON(req).
+		Config(j.config).
+		BodySerializer(j.marshal, req).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 }
@@ -347,9 +491,9 @@ func (j *jira) CreateIssueFromMap(ctx context.Context, req map[string]any) (Crea
 	err := requests.
 		URL(fmt.Sprintf("%s/issue", j.BaseUrl)).
 		Post().
-		Bearer(j.Token).
-		BodyJSON(UpsertIssueRequestFromMap{Fields: req}).
-		ToJSON(&created).
+		Config(j.config).
+		BodySerializer(j.marshal, UpsertIssueRequestFromMap{Fields: req}).
+		ToDeserializer(j.unmarshal, &created).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -364,8 +508,7 @@ func (j *jira) CreateIssue(ctx context.Context, req FieldsIssue) (CreatedIssueRe
 	if strings.TrimSpace(req.Summary) == "" {
 		return CreatedIssueResponse{}, fmt.Errorf("summary is empty")
 	}
-	// Тут в целом по проекту и типу задачи можно узнать какие поля ещё нужны и их проверять, но как будто нет смысла,
-	// жира в любом случае их вернет в ошибке
+	// Остальные обязательные поля и допустимые значения проверяет ValidateCreateIssue по метаданным проекта и типа задачи
 	if req.Project.ID == "" && strings.TrimSpace(req.Project.Key) == "" {
 		return CreatedIssueResponse{}, fmt.Errorf("project is empty (need id or key)")
 	}
@@ -377,9 +520,9 @@ func (j *jira) CreateIssue(ctx context.Context, req FieldsIssue) (CreatedIssueRe
 	err := requests.
 		URL(fmt.Sprintf("%s/issue", j.BaseUrl)).
 		Post().
-		Bearer(j.Token).
-		BodyJSON(UpsertIssueRequest{Fields: req}).
-		ToJSON(&created).
+		Config(j.config).
+		BodySerializer(j.marshal, UpsertIssueRequest{Fields: req}).
+		ToDeserializer(j.unmarshal, &created).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -392,22 +535,50 @@ func (j *jira) GetIssueTypeMeta(ctx context.Context, projectKey, issueTypeId str
 	resp := &IssueTypeMeta{}
 	err := requests.
 		URL(fmt.Sprintf("%s/issue/createmeta/%s/issuetypes/%s", j.BaseUrl, projectKey, issueTypeId)).
-		Bearer(j.Token).
-		ToJSON(resp).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, resp).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
 		return nil, err
 	}
+	// Пользователи в Jira Cloud передаются по accountId, это нужно знать при сборке значений полей
+	for i := range resp.Values {
+		resp.Values[i].cloud = j.Cloud
+	}
 	return resp, nil
 }
 
+// issueTypeIdByName — id типа задачи проекта по названию из create-meta, без учёта регистра
+func (j *jira) issueTypeIdByName(ctx context.Context, projectKey, name string) (string, error) {
+	var resp struct {
+		Values []IssueField `json:"values"`
+	}
+	err := requests.
+		URL(fmt.Sprintf("%s/issue/createmeta/%s/issuetypes", j.BaseUrl, projectKey)).
+		Param("maxResults", "1000").
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &resp).
+		AddValidator(validateStatus).
+		Fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get issue types of project %s: %w", projectKey, err)
+	}
+	name = strings.TrimSpace(name)
+	for _, issueType := range resp.Values {
+		if strings.EqualFold(issueType.Name, name) {
+			return issueType.ID, nil
+		}
+	}
+	return "", fmt.Errorf("issuetype %q not found in project %s", name, projectKey)
+}
+
 func (j *jira) GetJiraProjects(ctx context.Context) ([]JiraProject, error) {
 	var projects []JiraProject
 	err := requests.
 		URL(fmt.Sprintf("%s/project", j.BaseUrl)).
-		Bearer(j.Token).
-		ToJSON(&projects).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &projects).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -420,8 +591,8 @@ func (j *jira) GetJiraProjectComponents(ctx context.Context, projectKey string)
 	var components []JiraComponent
 	err := requests.
 		URL(fmt.Sprintf("%s/project/%s/components", j.BaseUrl, projectKey)).
-		Bearer(j.Token).
-		ToJSON(&components).
+		Config(j.config).
+		ToDeserializer(j.unmarshal, &components).
 		AddValidator(validateStatus).
 		Fetch(ctx)
 	if err != nil {
@@ -0,0 +1,116 @@
+package jira
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CallInfo — сведения об одном запросе к Jira для аудита
+type CallInfo struct {
+	Method      string
+	URL         string
+	RequestBody []byte // для multipart-запросов (вложения) не сохраняется
+	StatusCode  int
+	Duration    time.Duration
+	DryRun      bool  // запрос не отправлялся, ответ синтетический
+	Err         error // ошибка транспорта, ошибки по статусу видны в StatusCode
+}
+
+// Observer — получает сведения о каждом запросе клиента
+type Observer func(call CallInfo)
+
+// Option — дополнительная настройка клиента Jira
+type Option func(j *jira)
+
+// WithObserver — подключить наблюдателя за всеми запросами
+func WithObserver(observer Observer) Option {
+	return func(j *jira) {
+		j.observer = observer
+	}
+}
+
+// WithDryRun — режим без изменений: изменяющие запросы не отправляются, а пишутся в лог,
+// и методы получают синтетический успешный ответ. Чтение работает как обычно
+func WithDryRun(logger *slog.Logger) Option {
+	return func(j *jira) {
+		if logger == nil {
+			logger = slog.Default()
+		}
+		j.dryRun = true
+		j.logger = logger
+	}
+}
+
+// DryRunIssueKey — ключ задачи в синтетическом ответе на создание задачи в режиме dry-run
+const DryRunIssueKey = "DRYRUN-0"
+
+type observingTransport struct {
+	j    *jira
+	next http.RoundTripper
+}
+
+func (t observingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := CallInfo{Method: req.Method, URL: req.URL.String(), DryRun: t.j.dryRun && isMutatingRequest(req)}
+	if req.Body != nil && !strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/") {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		call.RequestBody = body
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	start := time.Now()
+	var resp *http.Response
+	if call.DryRun {
+		t.j.logger.Info("jira dry-run", "method", call.Method, "url", call.URL, "body", string(call.RequestBody))
+		resp = dryRunResponse(req)
+	} else {
+		resp, call.Err = t.next.RoundTrip(req)
+	}
+	call.Duration = time.Since(start)
+	if resp != nil {
+		call.StatusCode = resp.StatusCode
+	}
+	if t.j.observer != nil {
+		t.j.observer(call)
+	}
+	return resp, call.Err
+}
+
+// isMutatingRequest — изменяет ли запрос данные. Поиск в Jira делается POST-запросом, но ничего не меняет
+func isMutatingRequest(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return false
+	}
+	path := strings.TrimRight(req.URL.Path, "/")
+	return !strings.HasSuffix(path, "/search") && !strings.HasSuffix(path, "/search/jql")
+}
+
+// dryRunResponse — синтетический успешный ответ, который можно разобрать в ожидаемую методом структуру
+func dryRunResponse(req *http.Request) *http.Response {
+	body := "{}"
+	path := strings.TrimRight(req.URL.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "/attachments"):
+		body = "[]"
+	case req.Method == http.MethodPost && strings.HasSuffix(path, "/issue"):
+		body = `{"id": "0", "key": "` + DryRunIssueKey + `"}`
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,78 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserverAndDryRun(t *testing.T) {
+	var serverCalls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/issue/KEY-1", func(w http.ResponseWriter, r *http.Request) {
+		serverCalls = append(serverCalls, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(IssueJira{Key: "KEY-1"}))
+	})
+	mux.HandleFunc("/issue/KEY-1/comment", func(w http.ResponseWriter, r *http.Request) {
+		serverCalls = append(serverCalls, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		serverCalls = append(serverCalls, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(SearchResponse{}))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	t.Run("01. Наблюдатель видит все запросы", func(t *testing.T) {
+		serverCalls = nil
+		var calls []CallInfo
+		j := NewJira(srv.URL, "token", WithObserver(func(call CallInfo) { calls = append(calls, call) }))
+		require.NoError(t, j.CommentIssue(ctx, "KEY-1", "комментарий"))
+		_, err := j.GetIssueById(ctx, "KEY-1")
+		require.NoError(t, err)
+
+		require.Len(t, calls, 2)
+		require.Equal(t, http.MethodPost, calls[0].Method)
+		require.Equal(t, srv.URL+"/issue/KEY-1/comment", calls[0].URL)
+		require.JSONEq(t, `{"body": "комментарий"}`, string(calls[0].RequestBody))
+		require.Equal(t, http.StatusCreated, calls[0].StatusCode)
+		require.False(t, calls[0].DryRun)
+		require.Equal(t, http.StatusOK, calls[1].StatusCode)
+		require.Len(t, serverCalls, 2)
+	})
+
+	t.Run("02. Dry-run не отправляет изменяющие запросы", func(t *testing.T) {
+		serverCalls = nil
+		var calls []CallInfo
+		j := NewJira(srv.URL, "token", WithDryRun(nil), WithObserver(func(call CallInfo) { calls = append(calls, call) }))
+
+		require.NoError(t, j.CommentIssue(ctx, "KEY-1", "комментарий"))
+		require.NoError(t, j.UpdateIssue(ctx, "KEY-1", FieldsIssue{Summary: "Новая тема"}))
+		created, err := j.CreateIssue(ctx, FieldsIssue{
+			Summary:   "Тест",
+			Project:   JiraProject{IssueField: IssueField{Key: "TEST"}},
+			IssueType: IssueField{ID: Issue.Type.Task},
+		})
+		require.NoError(t, err)
+		require.Equal(t, DryRunIssueKey, created.Key)
+
+		// Чтение и поиск (POST /search) выполняются по-настоящему
+		_, err = j.GetIssueById(ctx, "KEY-1")
+		require.NoError(t, err)
+		_, err = j.SearchTasks(ctx, "project = TEST", 10, 0)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"GET /issue/KEY-1", "POST /search"}, serverCalls)
+		require.Len(t, calls, 5)
+		require.True(t, calls[0].DryRun)
+		require.False(t, calls[4].DryRun)
+	})
+}
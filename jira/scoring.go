@@ -0,0 +1,129 @@
+package jira
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ScoreFunc — функция оценки задачи, результат записывается в поле Weighted Job
+type ScoreFunc func(fields FieldsIssue) float64
+
+// WSJF — Weighted Shortest Job First: Business Value / Story Points с округлением до сотых.
+// Задачи без оценки получают 0 и уходят в конец очереди
+func WSJF(fields FieldsIssue) float64 {
+	if fields.StoryPoints <= 0 {
+		return 0
+	}
+	return math.Round(fields.BusinessValue/fields.StoryPoints*100) / 100
+}
+
+// BacklogScoringJql — JQL задач проекта в статусах Очередь и Оценено, которые участвуют в расчёте очереди
+func BacklogScoringJql(projectKey string) string {
+	return fmt.Sprintf("project = %s AND status in (%s, %s)", projectKey, Issue.Status.Backlog, Issue.Status.Rated)
+}
+
+type ScoringOptions struct {
+	Jql    string    // задачи для оценки, например BacklogScoringJql
+	Score  ScoreFunc // по умолчанию WSJF
+	DryRun bool      // только посчитать, не записывать в Jira
+}
+
+// IssueScore — оценка задачи и её место в очереди до и после расчёта. Ранги начинаются с 1
+type IssueScore struct {
+	Key      string
+	Summary  string
+	OldScore float64
+	NewScore float64
+	OldRank  int
+	NewRank  int
+}
+
+// RankDelta — на сколько позиций задача поднялась (положительное) или опустилась (отрицательное)
+func (s IssueScore) RankDelta() int {
+	return s.OldRank - s.NewRank
+}
+
+type ScoringReport struct {
+	Scores  []IssueScore // отсортированы по новому рангу
+	Updated []string     // ключи задач, у которых записано новое значение Weighted Job
+}
+
+// Moved — задачи, сменившие позицию в очереди с прошлого запуска
+func (r ScoringReport) Moved() []IssueScore {
+	var moved []IssueScore
+	for _, s := range r.Scores {
+		if s.RankDelta() != 0 {
+			moved = append(moved, s)
+		}
+	}
+	return moved
+}
+
+// RunScoring — пересчитывает оценку задач и записывает её в Weighted Job.
+// Прошлый запуск восстанавливается по текущим значениям Weighted Job, поэтому отдельное хранилище не нужно
+func RunScoring(ctx context.Context, api ApiJira, opts ScoringOptions) (ScoringReport, error) {
+	if opts.Jql == "" {
+		return ScoringReport{}, fmt.Errorf("jql is empty")
+	}
+	score := opts.Score
+	if score == nil {
+		score = WSJF
+	}
+	issues, err := api.SearchAllTasks(ctx, opts.Jql,
+		Issue.Fields.Summary, Issue.Fields.BusinessValue, Issue.Fields.StoryPoints, Issue.Fields.WeightedJob)
+	if err != nil {
+		return ScoringReport{}, fmt.Errorf("failed to search issues for scoring: %w", err)
+	}
+
+	scores := make([]IssueScore, 0, len(issues))
+	for _, issue := range issues {
+		scores = append(scores, IssueScore{
+			Key:      issue.Key,
+			Summary:  issue.Fields.Summary,
+			OldScore: issue.Fields.WeightedJob,
+			NewScore: score(issue.Fields),
+		})
+	}
+	rankScores(scores)
+
+	report := ScoringReport{Scores: scores}
+	for _, s := range scores {
+		if s.NewScore == s.OldScore || opts.DryRun {
+			continue
+		}
+		if err := updateWeightedJob(ctx, api, s.Key, s.NewScore); err != nil {
+			return report, fmt.Errorf("failed to update weighted job of %s: %w", s.Key, err)
+		}
+		report.Updated = append(report.Updated, s.Key)
+	}
+	return report, nil
+}
+
+func updateWeightedJob(ctx context.Context, api ApiJira, issueKey string, value float64) error {
+	if value == 0 {
+		// Нулевое значение FieldsIssue не сериализует из-за omitzero, поэтому отправляем его явно
+		return api.UpdateIssueFromMap(ctx, issueKey, map[string]any{Issue.Fields.WeightedJob: value})
+	}
+	return api.UpdateIssue(ctx, issueKey, FieldsIssue{WeightedJob: value})
+}
+
+// rankScores — проставляет старый и новый ранги и сортирует по новому.
+// При равной оценке порядок определяется ключом задачи, чтобы ранги были стабильными между запусками
+func rankScores(scores []IssueScore) {
+	byScore := func(score func(IssueScore) float64) func(a, b IssueScore) int {
+		return func(a, b IssueScore) int {
+			return cmp.Or(cmp.Compare(score(b), score(a)), cmp.Compare(a.Key, b.Key))
+		}
+	}
+	slices.SortFunc(scores, byScore(func(s IssueScore) float64 { return s.OldScore }))
+	for i := range scores {
+		scores[i].OldRank = i + 1
+	}
+	slices.SortFunc(scores, byScore(func(s IssueScore) float64 { return s.NewScore }))
+	for i := range scores {
+		scores[i].NewRank = i + 1
+	}
+}
@@ -0,0 +1,82 @@
+package jira
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// scoringJira — заглушка ApiJira: поиск отдаёт заранее заданные задачи, обновления запоминаются
+type scoringJira struct {
+	ApiJira
+	issues  []IssueJira
+	updates map[string]float64
+}
+
+func (s *scoringJira) SearchAllTasks(_ context.Context, _ string, _ ...string) ([]IssueJira, error) {
+	return s.issues, nil
+}
+
+func (s *scoringJira) UpdateIssue(_ context.Context, issueKey string, req FieldsIssue) error {
+	s.updates[issueKey] = req.WeightedJob
+	return nil
+}
+
+func (s *scoringJira) UpdateIssueFromMap(_ context.Context, issueKey string, req map[string]any) error {
+	s.updates[issueKey] = req[Issue.Fields.WeightedJob].(float64)
+	return nil
+}
+
+func TestWSJF(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields FieldsIssue
+		want   float64
+	}{
+		{name: "01. Обычная оценка", fields: FieldsIssue{BusinessValue: 5, StoryPoints: 8}, want: 0.63},
+		{name: "02. Без Story Points", fields: FieldsIssue{BusinessValue: 5}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, WSJF(tt.fields))
+		})
+	}
+}
+
+func TestRunScoring(t *testing.T) {
+	newApi := func() *scoringJira {
+		return &scoringJira{
+			issues: []IssueJira{
+				{Key: "KEY-1", Fields: FieldsIssue{BusinessValue: 1, StoryPoints: 8, WeightedJob: 2}},
+				{Key: "KEY-2", Fields: FieldsIssue{BusinessValue: 8, StoryPoints: 2, WeightedJob: 4}},
+				{Key: "KEY-3", Fields: FieldsIssue{BusinessValue: 3, StoryPoints: 3, WeightedJob: 1.5}},
+				{Key: "KEY-4", Fields: FieldsIssue{BusinessValue: 3, WeightedJob: 0.5}},
+			},
+			updates: map[string]float64{},
+		}
+	}
+
+	api := newApi()
+	report, err := RunScoring(context.Background(), api, ScoringOptions{Jql: BacklogScoringJql("TEST")})
+	require.NoError(t, err)
+
+	var order []string
+	for _, s := range report.Scores {
+		order = append(order, s.Key)
+	}
+	require.Equal(t, []string{"KEY-2", "KEY-3", "KEY-1", "KEY-4"}, order)
+	require.Equal(t, map[string]float64{"KEY-1": 0.13, "KEY-3": 1, "KEY-4": 0}, api.updates)
+	require.Equal(t, []string{"KEY-3", "KEY-1", "KEY-4"}, report.Updated)
+
+	moved := report.Moved()
+	require.Len(t, moved, 2)
+	require.Equal(t, "KEY-3", moved[0].Key)
+	require.Equal(t, 1, moved[0].RankDelta())
+	require.Equal(t, -1, moved[1].RankDelta())
+
+	dryApi := newApi()
+	_, err = RunScoring(context.Background(), dryApi, ScoringOptions{Jql: BacklogScoringJql("TEST"), DryRun: true})
+	require.NoError(t, err)
+	require.Empty(t, dryApi.updates)
+}
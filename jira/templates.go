@@ -3,6 +3,8 @@ package jira
 var Issue = newIssue()
 var EventType = newEventTypes()
 var Changelog = newChangelogs()
+var SprintState = newSprintStates()
+var LinkType = newLinkTypes()
 
 type Issues struct {
 	Status        status        // Статус
@@ -265,6 +267,7 @@ type changelogField struct {
 	DueDate       string // Срок исполнения
 	Participants  string // Участники
 	Product       string // Продукт/Модуль
+	Resolution    string // Решение
 
 	LearnTime               string // Месяц и год обучения
 	LearnForWho             string // Для кого еще подходит это обучение?
@@ -297,6 +300,7 @@ func newChangelogFields() changelogField {
 		DueDate:       "Срок исполнения",
 		Participants:  "Участники",
 		Product:       "Продукт/Модуль",
+		Resolution:    "resolution",
 
 		LearnTime:               "Месяц и год обучения",
 		LearnForWho:             "Для кого еще подходит это обучение?",
@@ -389,8 +393,10 @@ type fieldsIssue struct {
 	Summary           string // summary
 	Description       string // description
 	Priority          string // priority
+	Resolution        string // resolution
 	Project           string // project
 	Type              string // type
+	IssueType         string // issuetype
 	SupportEmailTopic string // customfield_16881
 	ProductSup        string // customfield_16880
 	AffectedModules   string // customfield_17086
@@ -413,8 +419,10 @@ type fieldsIssue struct {
 	LearnUsefulContacts     string // Полезные контакты или конструктивные диалоги
 	LearnMaterialsLink      string // Ссылка на материалы мероприятия
 
-	Parent   string // parent
-	SubTasks string // subtasks
+	Parent      string // parent
+	SubTasks    string // subtasks
+	Attachments string // attachment
+	IssueLinks  string // issuelinks
 }
 
 func newIssueFields() fieldsIssue {
@@ -437,8 +445,10 @@ func newIssueFields() fieldsIssue {
 		Summary:            "summary",
 		Description:        "description",
 		Priority:           "priority",
+		Resolution:         "resolution",
 		Project:            "project",
 		Type:               "type",
+		IssueType:          "issuetype",
 		SupportEmailTopic:  "customfield_16881",
 		ProductSup:         "customfield_16880",
 		AffectedModules:    "customfield_17086",
@@ -461,7 +471,39 @@ func newIssueFields() fieldsIssue {
 		LearnUsefulContacts:     "customfield_16985",
 		LearnMaterialsLink:      "customfield_16986",
 
-		Parent:   "parent",
-		SubTasks: "subtasks",
+		Parent:      "parent",
+		SubTasks:    "subtasks",
+		Attachments: "attachment",
+		IssueLinks:  "issuelinks",
+	}
+}
+
+type sprintState struct {
+	Future string // Будущий
+	Active string // Активный
+	Closed string // Завершен
+}
+
+func newSprintStates() sprintState {
+	return sprintState{
+		Future: "future",
+		Active: "active",
+		Closed: "closed",
+	}
+}
+
+type linkType struct {
+	Cloners   string // Клонирует
+	Relates   string // Связана с
+	Blocks    string // Блокирует
+	Duplicate string // Дублирует
+}
+
+func newLinkTypes() linkType {
+	return linkType{
+		Cloners:   "Cloners",
+		Relates:   "Relates",
+		Blocks:    "Blocks",
+		Duplicate: "Duplicate",
 	}
 }
@@ -0,0 +1,332 @@
+package jira
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TriageRule — правило разбора входящих обращений: если задача подходит под When, выполняются действия Then.
+// Правила применяются по порядку, Stop прекращает проверку следующих правил
+type TriageRule struct {
+	Name string          `yaml:"name"`
+	When TriageCondition `yaml:"when"`
+	Then TriageActions   `yaml:"then"`
+	Stop bool            `yaml:"stop"`
+}
+
+// TriageCondition — условия правила. Внутри списка достаточно одного совпадения, все заполненные списки должны совпасть.
+// Значения полей-списков (заказчик, продукт, источник) сравниваются и с ID, и с названием без учёта регистра
+type TriageCondition struct {
+	Projects           []string `yaml:"projects"`           // ключи проектов
+	IssueTypes         []string `yaml:"issueTypes"`         // Issue.Type
+	Statuses           []string `yaml:"statuses"`           // Issue.Status
+	SourceRequests     []string `yaml:"sourceRequests"`     // Issue.SourceRequest
+	Customers          []string `yaml:"customers"`          // Заказчик
+	Products           []string `yaml:"products"`           // Продукт (поддержка)
+	Labels             []string `yaml:"labels"`             // хотя бы одна из меток
+	SummaryContains    []string `yaml:"summaryContains"`    // подстрока темы
+	EmailTopicContains []string `yaml:"emailTopicContains"` // подстрока темы письма в поддержку
+}
+
+// TriageActions — действия правила
+type TriageActions struct {
+	Component           string   `yaml:"component"`           // название компонента проекта
+	AssignComponentLead bool     `yaml:"assignComponentLead"` // назначить на руководителя компонента
+	Assignee            string   `yaml:"assignee"`            // логин (accountId в Jira Cloud) исполнителя, приоритетнее руководителя компонента
+	Priority            string   `yaml:"priority"`            // Issue.Priority
+	Labels              []string `yaml:"labels"`
+	Status              string   `yaml:"status"` // целевой статус, переход через TransitionToStatus
+	Comment             string   `yaml:"comment"`
+}
+
+type triageRulesFile struct {
+	Rules []TriageRule `yaml:"rules"`
+}
+
+// LoadTriageRules — читает правила из YAML вида `rules: [{name, when, then, stop}]`
+func LoadTriageRules(r io.Reader) ([]TriageRule, error) {
+	var file triageRulesFile
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse triage rules: %w", err)
+	}
+	for i, rule := range file.Rules {
+		if strings.TrimSpace(rule.Name) == "" {
+			return nil, fmt.Errorf("triage rule #%d has no name", i+1)
+		}
+		if rule.Then.isEmpty() {
+			return nil, fmt.Errorf("triage rule %q has no actions", rule.Name)
+		}
+		if rule.Then.AssignComponentLead && rule.Then.Component == "" {
+			return nil, fmt.Errorf("triage rule %q assigns component lead without component", rule.Name)
+		}
+	}
+	return file.Rules, nil
+}
+
+// LoadTriageRulesFile — читает правила из YAML-файла
+func LoadTriageRulesFile(path string) ([]TriageRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadTriageRules(f)
+}
+
+func (a TriageActions) isEmpty() bool {
+	return a.Component == "" && !a.AssignComponentLead && a.Assignee == "" && a.Priority == "" &&
+		len(a.Labels) == 0 && a.Status == "" && a.Comment == ""
+}
+
+// Match — подходит ли задача под условие
+func (c TriageCondition) Match(issue IssueJira) bool {
+	f := issue.Fields
+	return matchAny(c.Projects, f.Project.Key) &&
+		matchAny(c.IssueTypes, f.IssueType.ID) &&
+		matchAny(c.Statuses, f.Status.ID) &&
+		matchAny(c.SourceRequests, f.SourceRequest.ID, f.SourceRequest.Value) &&
+		matchAny(c.Customers, f.Customer.ID, f.Customer.Value) &&
+		matchAny(c.Products, f.ProductSup.ID, f.ProductSup.Value) &&
+		matchAny(c.Labels, f.Labels...) &&
+		containsAny(c.SummaryContains, f.Summary) &&
+		containsAny(c.EmailTopicContains, f.SupportEmailTopic)
+}
+
+// matchAny — пустое условие подходит всегда, иначе нужно совпадение хотя бы одного значения
+func matchAny(expected []string, actual ...string) bool {
+	if len(expected) == 0 {
+		return true
+	}
+	for _, e := range expected {
+		for _, a := range actual {
+			if a != "" && strings.EqualFold(e, a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsAny(substrings []string, s string) bool {
+	if len(substrings) == 0 {
+		return true
+	}
+	s = strings.ToLower(s)
+	for _, sub := range substrings {
+		if strings.Contains(s, strings.ToLower(sub)) {
+			return true
+		}
+	}
+	return false
+}
+
+// TriageResult — что сделано (или было бы сделано в режиме DryRun) с задачей
+type TriageResult struct {
+	IssueKey string
+	Rules    []string // названия сработавших правил
+	Actions  []string // описание выполненных действий
+	DryRun   bool
+}
+
+// TriageEngine — движок разбора входящих обращений по правилам
+type TriageEngine struct {
+	api    ApiJira
+	rules  []TriageRule
+	dryRun bool
+	logger *slog.Logger
+}
+
+// NewTriageEngine — в режиме dryRun действия только пишутся в лог, в Jira ничего не меняется.
+// Для движка решает именно dryRun: от него зависят вызовы api и TriageResult.DryRun. Клиент, созданный
+// с WithDryRun, дополнительно не отправляет изменяющие запросы, но движок об этом не знает и считает
+// действия выполненными, поэтому для пробного разбора нужно передавать dryRun = true
+func NewTriageEngine(api ApiJira, rules []TriageRule, dryRun bool, logger *slog.Logger) *TriageEngine {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &TriageEngine{api: api, rules: rules, dryRun: dryRun, logger: logger}
+}
+
+// MatchRules — правила, сработавшие для задачи, с учётом Stop
+func (e *TriageEngine) MatchRules(issue IssueJira) []TriageRule {
+	var matched []TriageRule
+	for _, rule := range e.rules {
+		if !rule.When.Match(issue) {
+			continue
+		}
+		matched = append(matched, rule)
+		if rule.Stop {
+			break
+		}
+	}
+	return matched
+}
+
+// HandleWebhook — разбирает задачу из вебхука создания, остальные события пропускаются
+func (e *TriageEngine) HandleWebhook(ctx context.Context, webhook WebhookIssue) (TriageResult, error) {
+	if webhook.IssueEventType != EventType.Created {
+		return TriageResult{IssueKey: webhook.Issue.Key, DryRun: e.dryRun}, nil
+	}
+	return e.Triage(ctx, webhook.Issue)
+}
+
+// TriageAll — разбирает задачи, например результат периодического поиска новых обращений.
+// Ошибка по одной задаче не останавливает разбор остальных
+func (e *TriageEngine) TriageAll(ctx context.Context, issues []IssueJira) ([]TriageResult, error) {
+	results := make([]TriageResult, 0, len(issues))
+	var errs []error
+	for _, issue := range issues {
+		res, err := e.Triage(ctx, issue)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", issue.Key, err))
+		}
+		results = append(results, res)
+	}
+	if len(errs) > 0 {
+		return results, fmt.Errorf("triage failed for %d issues: %w", len(errs), errors.Join(errs...))
+	}
+	return results, nil
+}
+
+// Triage — применяет к задаче все сработавшие правила. Одиночные значения (компонент, исполнитель,
+// приоритет, статус) берутся из последнего сработавшего правила, метки и комментарии накапливаются
+func (e *TriageEngine) Triage(ctx context.Context, issue IssueJira) (TriageResult, error) {
+	res := TriageResult{IssueKey: issue.Key, DryRun: e.dryRun}
+	matched := e.MatchRules(issue)
+	if len(matched) == 0 {
+		return res, nil
+	}
+	var plan TriageActions
+	var comments []string
+	for _, rule := range matched {
+		res.Rules = append(res.Rules, rule.Name)
+		plan = mergeTriageActions(plan, rule.Then)
+		if rule.Then.Comment != "" {
+			comments = append(comments, rule.Then.Comment)
+		}
+	}
+	plan.Comment = strings.Join(comments, "\n\n")
+
+	fields := map[string]any{}
+	assignee := plan.Assignee
+	if plan.Component != "" {
+		component, err := e.findComponent(ctx, issue.Fields.Project.Key, plan.Component)
+		if err != nil {
+			return res, err
+		}
+		if !slices.ContainsFunc(issue.Fields.Components, func(c JiraComponent) bool { return c.ID == component.ID }) {
+			components := []map[string]any{{"id": component.ID}}
+			for _, c := range issue.Fields.Components {
+				components = append(components, map[string]any{"id": c.ID})
+			}
+			fields[Issue.Fields.Components] = components
+			res.Actions = append(res.Actions, fmt.Sprintf("add component %q", component.Name))
+		}
+		if assignee == "" && plan.AssignComponentLead {
+			assignee = component.Lead.Identity()
+		}
+	}
+	if plan.Priority != "" && plan.Priority != issue.Fields.Priority.ID {
+		fields[Issue.Fields.Priority] = map[string]any{"id": plan.Priority}
+		res.Actions = append(res.Actions, fmt.Sprintf("set priority %s", plan.Priority))
+	}
+	if len(fields) > 0 {
+		if err := e.do(ctx, issue.Key, "update fields", func(ctx context.Context) error {
+			return e.api.UpdateIssueFromMap(ctx, issue.Key, fields)
+		}); err != nil {
+			return res, err
+		}
+	}
+	for _, label := range plan.Labels {
+		if issue.Fields.HasLabel(label) {
+			continue
+		}
+		res.Actions = append(res.Actions, fmt.Sprintf("add label %q", label))
+		if err := e.do(ctx, issue.Key, "add label "+label, func(ctx context.Context) error {
+			return e.api.AddLabel(ctx, issue.Key, label)
+		}); err != nil {
+			return res, err
+		}
+	}
+	if assignee != "" && assignee != issue.Fields.Assignee.Identity() {
+		res.Actions = append(res.Actions, fmt.Sprintf("assign to %s", assignee))
+		if err := e.do(ctx, issue.Key, "assign to "+assignee, func(ctx context.Context) error {
+			return e.api.UpdateIssueAssignee(ctx, issue.Key, assignee)
+		}); err != nil {
+			return res, err
+		}
+	}
+	if plan.Comment != "" {
+		res.Actions = append(res.Actions, "add comment")
+		if err := e.do(ctx, issue.Key, "add comment", func(ctx context.Context) error {
+			return e.api.CommentIssue(ctx, issue.Key, plan.Comment)
+		}); err != nil {
+			return res, err
+		}
+	}
+	if plan.Status != "" && plan.Status != issue.Fields.Status.ID {
+		res.Actions = append(res.Actions, fmt.Sprintf("transition to status %s", plan.Status))
+		if err := e.do(ctx, issue.Key, "transition to status "+plan.Status, func(ctx context.Context) error {
+			return e.api.TransitionToStatus(ctx, issue.Key, plan.Status)
+		}); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// do — выполняет действие или, в режиме dryRun, только пишет его в лог
+func (e *TriageEngine) do(ctx context.Context, issueKey, action string, fn func(ctx context.Context) error) error {
+	if e.dryRun {
+		e.logger.Info("triage dry-run", "issue", issueKey, "action", action)
+		return nil
+	}
+	if err := fn(ctx); err != nil {
+		return fmt.Errorf("triage action %q failed: %w", action, err)
+	}
+	e.logger.Debug("triage", "issue", issueKey, "action", action)
+	return nil
+}
+
+func (e *TriageEngine) findComponent(ctx context.Context, projectKey, name string) (JiraComponent, error) {
+	components, err := e.api.GetJiraProjectComponents(ctx, projectKey)
+	if err != nil {
+		return JiraComponent{}, fmt.Errorf("failed to get components of project %s: %w", projectKey, err)
+	}
+	for _, c := range components {
+		if strings.EqualFold(c.Name, name) {
+			return c, nil
+		}
+	}
+	return JiraComponent{}, fmt.Errorf("component %q not found in project %s", name, projectKey)
+}
+
+func mergeTriageActions(base, next TriageActions) TriageActions {
+	if next.Component != "" {
+		base.Component = next.Component
+		base.AssignComponentLead = next.AssignComponentLead
+	}
+	if next.Assignee != "" {
+		base.Assignee = next.Assignee
+	}
+	if next.Priority != "" {
+		base.Priority = next.Priority
+	}
+	if next.Status != "" {
+		base.Status = next.Status
+	}
+	for _, label := range next.Labels {
+		base.Labels = appendUniqueString(base.Labels, label)
+	}
+	return base
+}
@@ -0,0 +1,141 @@
+package jira
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const triageTestRules = `
+rules:
+  - name: Почта по продукту Jira
+    when:
+      projects: [SUP]
+      sourceRequests: ["12675"]
+      summaryContains: [jira]
+    then:
+      component: Jira
+      assignComponentLead: true
+      priority: "3"
+      labels: [auto-triage]
+      status: "11821"
+  - name: Спам
+    when:
+      emailTopicContains: [unsubscribe]
+    then:
+      status: "11820"
+      comment: Не требует реакции
+    stop: true
+  - name: Не сработает после stop
+    when: {}
+    then:
+      labels: [never]
+`
+
+// triageJira — заглушка ApiJira, запоминает вызовы изменяющих методов
+type triageJira struct {
+	ApiJira
+	calls []string
+	err   error // ошибка изменяющих методов
+}
+
+func (t *triageJira) GetJiraProjectComponents(_ context.Context, _ string) ([]JiraComponent, error) {
+	return []JiraComponent{{IssueField: IssueField{ID: "48150", Name: "Jira"}, Lead: JiraUser{Name: "lead"}}}, nil
+}
+
+func (t *triageJira) UpdateIssueFromMap(_ context.Context, issueKey string, req map[string]any) error {
+	t.calls = append(t.calls, "update "+issueKey)
+	return t.err
+}
+
+func (t *triageJira) AddLabel(_ context.Context, issueKey string, label string) error {
+	t.calls = append(t.calls, "label "+label)
+	return nil
+}
+
+func (t *triageJira) UpdateIssueAssignee(_ context.Context, issueKey, assigneeName string) error {
+	t.calls = append(t.calls, "assign "+assigneeName)
+	return nil
+}
+
+func (t *triageJira) CommentIssue(_ context.Context, issueKey, comment string) error {
+	t.calls = append(t.calls, "comment")
+	return nil
+}
+
+func (t *triageJira) TransitionToStatus(_ context.Context, issueKey, targetStatusId string) error {
+	t.calls = append(t.calls, "status "+targetStatusId)
+	return nil
+}
+
+func TestLoadTriageRules(t *testing.T) {
+	rules, err := LoadTriageRules(strings.NewReader(triageTestRules))
+	require.NoError(t, err)
+	require.Len(t, rules, 3)
+	require.Equal(t, []string{"12675"}, rules[0].When.SourceRequests)
+	require.True(t, rules[1].Stop)
+
+	_, err = LoadTriageRules(strings.NewReader("rules:\n  - name: Пустое\n    when: {}\n"))
+	require.ErrorContains(t, err, "has no actions")
+
+	_, err = LoadTriageRules(strings.NewReader("rules:\n  - name: Опечатка\n    then: {statuss: \"1\"}\n"))
+	require.Error(t, err)
+}
+
+func TestTriageEngine(t *testing.T) {
+	rules, err := LoadTriageRules(strings.NewReader(triageTestRules))
+	require.NoError(t, err)
+
+	issue := IssueJira{Key: "SUP-1", Fields: FieldsIssue{
+		Summary:           "Не работает Jira",
+		SupportEmailTopic: "Re: unsubscribe",
+		Project:           JiraProject{IssueField: IssueField{Key: "SUP"}},
+		SourceRequest:     IssueField{ID: Issue.SourceRequest.Mail},
+		Status:            IssueField{ID: Issue.Status.New},
+	}}
+
+	t.Run("01. Применение правил", func(t *testing.T) {
+		api := &triageJira{}
+		res, err := NewTriageEngine(api, rules, false, nil).Triage(context.Background(), issue)
+		require.NoError(t, err)
+		require.Equal(t, []string{"Почта по продукту Jira", "Спам"}, res.Rules)
+		require.Equal(t, []string{
+			"update SUP-1",
+			"label auto-triage",
+			"assign lead",
+			"comment",
+			"status " + Issue.Status.NoNeedReaction,
+		}, api.calls)
+	})
+
+	t.Run("02. Dry-run ничего не меняет", func(t *testing.T) {
+		api := &triageJira{}
+		res, err := NewTriageEngine(api, rules, true, nil).Triage(context.Background(), issue)
+		require.NoError(t, err)
+		require.True(t, res.DryRun)
+		require.Len(t, res.Actions, 6)
+		require.Empty(t, api.calls)
+	})
+
+	t.Run("03. Вебхук не о создании пропускается", func(t *testing.T) {
+		api := &triageJira{}
+		res, err := NewTriageEngine(api, rules, false, nil).HandleWebhook(context.Background(),
+			WebhookIssue{IssueEventType: EventType.Updated, Issue: issue})
+		require.NoError(t, err)
+		require.Empty(t, res.Rules)
+		require.Empty(t, api.calls)
+	})
+
+	t.Run("04. Ошибки разбора доступны через errors.Is", func(t *testing.T) {
+		api := &triageJira{err: context.Canceled}
+		second := issue
+		second.Key = "SUP-2"
+		res, err := NewTriageEngine(api, rules, false, nil).TriageAll(context.Background(), []IssueJira{issue, second})
+		require.ErrorIs(t, err, context.Canceled)
+		require.ErrorContains(t, err, "triage failed for 2 issues")
+		require.ErrorContains(t, err, "SUP-2: ")
+		require.Len(t, res, 2)
+	})
+}
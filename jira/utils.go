@@ -12,6 +12,10 @@ import (
 
 const TimeFormatJira = "2006-01-02T15:04:05.000-0700"
 
+// jiraTimeFormats — форматы, в которых Jira отдаёт время: основной Server/DC и Cloud,
+// а также RFC 3339 и дата без времени, которые встречаются в Cloud и в кастомных полях
+var jiraTimeFormats = []string{TimeFormatJira, time.RFC3339Nano, "2006-01-02"}
+
 type JiraTime struct {
 	time.Time
 }
@@ -24,6 +28,14 @@ func (j *JiraTime) UnmarshalJSON(b []byte) error {
 		return nil
 	}
 	t, err := time.Parse(TimeFormatJira, s)
+	for _, layout := range jiraTimeFormats[1:] {
+		if err == nil {
+			break
+		}
+		if fallback, fallbackErr := time.Parse(layout, s); fallbackErr == nil {
+			t, err = fallback, nil
+		}
+	}
 	if err != nil {
 		return err
 	}